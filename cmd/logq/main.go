@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zero-miao/go-utils/logger"
+	"github.com/zero-miao/go-utils/logger/logq"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-20 00:30
+// @File   : main.go
+// @Project: utils/cmd/logq
+// ==========================
+
+// logq 按时间顺序读取 logger 写入的日志文件(包括切割后的历史文件和 .gz 归档), 并按条件过滤.
+//
+//	logq -config logging.yaml -module app -since 1h -level warn -field status=500
+//	logq -output json app.log.2024-01-01.gz app.log
+//	logq lint logging.yaml
+//	logq config logging.yaml
+const usage = `usage:
+  logq [flags] [file ...]       查询日志
+  logq lint [-strict] file ...  检查配置, 有错误(-strict 时包括警告)时退出码为 1
+  logq config [file]            输出环境变量替换和覆盖后实际生效的配置, 没有参数时为默认配置
+
+指定 -config 时读取配置中 file, rotate_file handler 的所有日志文件, 否则读取参数中的文件(自动判断 console/json 格式).
+
+flags:
+`
+
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+func main() {
+	args := os.Args[1:]
+	run := query
+	if len(args) > 0 {
+		switch args[0] {
+		case "lint":
+			run, args = lint, args[1:]
+		case "config":
+			run, args = config, args[1:]
+		}
+	}
+	if err := run(args); err != nil {
+		if err != errLint {
+			fmt.Fprintln(os.Stderr, "logq:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func query(args []string) error {
+	fs := flag.NewFlagSet("logq", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	config := fs.String("config", "", "logging 配置文件")
+	modules := fs.String("module", "", "只读取这些 module, 逗号分隔")
+	since := fs.String("since", "", "开始时间, RFC3339, 2006-01-02 15:04:05 或距今的时长(如 1h)")
+	until := fs.String("until", "", "结束时间(不包含), 格式同 since")
+	level := fs.String("level", "", "最低等级")
+	output := fs.String("output", "console", "输出格式: console | json")
+	var fields stringList
+	fs.Var(&fields, "field", "字段条件, 可重复: key=value, key!=value, key~regex, key, !key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var q logq.Query
+	var err error
+	if q.Since, err = parseTime(*since); err != nil {
+		return err
+	}
+	if q.Until, err = parseTime(*until); err != nil {
+		return err
+	}
+	if *level != "" {
+		l, err := logger.ParseLevel(*level)
+		if err != nil {
+			return err
+		}
+		q.Level = l
+	}
+	if *modules != "" {
+		q.Modules = strings.Split(*modules, ",")
+	}
+	if q.Fields, err = logger.ParseFieldMatcher(fields); err != nil {
+		return err
+	}
+
+	var sources []logq.Source
+	if *config != "" {
+		data, err := os.ReadFile(*config)
+		if err != nil {
+			return err
+		}
+		cfg, err := logger.ParseConfig(data)
+		if err != nil {
+			return err
+		}
+		if sources, err = logq.Sources(cfg); err != nil {
+			return err
+		}
+	}
+	for _, path := range fs.Args() {
+		sources = append(sources, logq.Source{Filename: path})
+	}
+	if len(sources) == 0 {
+		fs.Usage()
+		return errors.New("no log file")
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	enc := json.NewEncoder(w)
+	return q.Run(sources, func(ent logq.Entry) error {
+		if *output == "json" {
+			return enc.Encode(ent)
+		}
+		_, err := fmt.Fprintln(w, formatEntry(ent))
+		return err
+	})
+}
+
+func formatEntry(ent logq.Entry) string {
+	parts := []string{ent.Time.Format("2006-01-02T15:04:05.000Z0700"), ent.Level.String()}
+	if ent.Module != "" {
+		parts = append(parts, ent.Module)
+	}
+	if ent.Caller != "" {
+		parts = append(parts, ent.Caller)
+	}
+	parts = append(parts, ent.Message)
+	if len(ent.Fields) > 0 {
+		data, _ := json.Marshal(ent.Fields)
+		parts = append(parts, string(data))
+	}
+	line := strings.Join(parts, "\t")
+	if ent.Stack != "" {
+		line += "\n" + ent.Stack
+	}
+	return line
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if du, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-du), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("invalid time: " + s)
+}
+
+var errLint = errors.New("lint failed")
+
+func lint(args []string) error {
+	fs := flag.NewFlagSet("logq lint", flag.ContinueOnError)
+	strict := fs.Bool("strict", false, "警告也视为失败")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("usage: logq lint [-strict] file ...")
+	}
+	failed := false
+	for _, path := range fs.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, p := range logger.Validate(data) {
+			fmt.Printf("%s: %s\n", path, p)
+			failed = failed || p.Severity == logger.ProblemError || *strict
+		}
+	}
+	if failed {
+		return errLint
+	}
+	return nil
+}
+
+func config(args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: logq config [file]")
+	}
+	var data []byte
+	if len(args) == 1 {
+		var err error
+		if data, err = os.ReadFile(args[0]); err != nil {
+			return err
+		}
+	}
+	resolved, err := logger.ResolveConfig(data)
+	if err != nil {
+		return err
+	}
+	os.Stdout.Write(resolved)
+	if len(resolved) > 0 && resolved[len(resolved)-1] != '\n' {
+		fmt.Println()
+	}
+	return nil
+}
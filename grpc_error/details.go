@@ -0,0 +1,132 @@
+package grpc_error
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-20 02:00
+// @File   : details.go
+// @Project: utils/grpc_error
+// ==========================
+
+// ErrorDomain ErrorInfo 中的 domain, 客户端只按 ErrorInfo 还原 domain 相同的错误.
+var ErrorDomain = "grpc_error"
+
+// DebugDetails 为 true 时, New 记录调用栈, GRPCStatus 附带 DebugInfo(调用栈和 cause) 以及 Info, 只应在开发和测试环境打开.
+var DebugDetails = false
+
+// FieldViolation 无效的请求参数.
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// details 返回 status 的 details:
+//
+//	ErrorInfo  reason 为错误编码, metadata 包括 name, DebugDetails 为 true 时还包括 info(json)
+//	BadRequest Violations 不为空时
+//	RetryInfo  RetryDelay 大于 0 时
+//	DebugInfo  DebugDetails 为 true 时
+func (e *AppError) details() []protoadapt.MessageV1 {
+	info := &errdetails.ErrorInfo{
+		Reason:   e.Code.Code,
+		Domain:   ErrorDomain,
+		Metadata: map[string]string{"name": e.Code.Name},
+	}
+	// Info 可能包含请求中的原始值(如 SomethingInvalid), 默认不发送给调用方.
+	if DebugDetails && e.Info != nil {
+		if data, err := json.Marshal(e.Info); err == nil {
+			info.Metadata["info"] = string(data)
+		} else {
+			info.Metadata["info"] = fmt.Sprint(e.Info)
+		}
+	}
+	res := []protoadapt.MessageV1{info}
+	if len(e.Violations) > 0 {
+		br := &errdetails.BadRequest{}
+		for _, v := range e.Violations {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: v.Field, Description: v.Description})
+		}
+		res = append(res, br)
+	}
+	if e.RetryDelay > 0 {
+		res = append(res, &errdetails.RetryInfo{RetryDelay: durationpb.New(e.RetryDelay)})
+	}
+	if DebugDetails {
+		debug := &errdetails.DebugInfo{}
+		if e.Err != nil {
+			debug.Detail = e.Err.Error()
+		}
+		if len(e.Stack) > 0 {
+			debug.StackEntries = strings.Split(strings.TrimSpace(string(e.Stack)), "\n")
+		}
+		res = append(res, debug)
+	}
+	return res
+}
+
+// FromError 还原客户端收到的错误, err 为 nil 时返回 nil.
+// err 本身是 AppError 时直接返回; status 附带本服务 domain 的 ErrorInfo 时按其中的错误编码还原(未注册的编码同样保留);
+// 其他错误为 EUnknown, Code.GRPCCode 为 status 的状态码, Err 为原始错误.
+// 不会发送邮件.
+func FromError(err error) *AppError {
+	if err == nil {
+		return nil
+	}
+	if e, ok := AsAppError(err); ok {
+		return e
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return &AppError{Code: codeTypeMap[EUnknown], Desc: err.Error(), Err: err}
+	}
+	e := &AppError{Code: codeTypeMap[EUnknown], Desc: st.Message(), Err: err}
+	e.Code.GRPCCode = st.Code()
+	for _, d := range st.Details() {
+		switch detail := d.(type) {
+		case *errdetails.ErrorInfo:
+			if detail.Domain != ErrorDomain {
+				continue
+			}
+			t, ok := codeTypeMap[detail.Reason]
+			if !ok {
+				t = ErrorType{Code: detail.Reason, Name: detail.Metadata["name"]}
+			}
+			t.GRPCCode = st.Code()
+			e.Code = t
+			e.Err = nil
+			if raw, ok := detail.Metadata["info"]; ok {
+				var info interface{}
+				if json.Unmarshal([]byte(raw), &info) == nil {
+					e.Info = info
+				} else {
+					e.Info = raw
+				}
+			}
+		case *errdetails.BadRequest:
+			for _, v := range detail.FieldViolations {
+				e.Violations = append(e.Violations, FieldViolation{Field: v.Field, Description: v.Description})
+			}
+		case *errdetails.RetryInfo:
+			e.RetryDelay = detail.RetryDelay.AsDuration()
+		case *errdetails.DebugInfo:
+			if detail.Detail != "" {
+				e.Err = errors.New(detail.Detail)
+			}
+			if len(detail.StackEntries) > 0 {
+				e.Stack = []byte(strings.Join(detail.StackEntries, "\n"))
+			}
+		}
+	}
+	return e
+}
@@ -0,0 +1,75 @@
+package grpc_error
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// roundTrip 模拟 status 经过网络传输.
+func roundTrip(t *testing.T, err error) error {
+	st, _ := status.FromError(err)
+	data, e := proto.Marshal(st.Proto())
+	if e != nil {
+		t.Fatal(e)
+	}
+	var p spb.Status
+	if e := proto.Unmarshal(data, &p); e != nil {
+		t.Fatal(e)
+	}
+	return status.ErrorProto(&p)
+}
+
+func TestFromError(t *testing.T) {
+	e := SomethingInvalid("age", -1)
+	e.RetryDelay = 2 * time.Second
+	got := FromError(roundTrip(t, e))
+	if got.Code.Code != ERequest || got.Code.GRPCCode != codes.InvalidArgument || got.Desc != "age invalid" {
+		t.Errorf("got=%+v", got)
+	}
+	if !errors.Is(got, ErrRequest) {
+		t.Error("decoded error should match ErrRequest")
+	}
+	if len(got.Violations) != 1 || got.Violations[0].Field != "age" || got.Violations[0].Description != "invalid" || got.RetryDelay != 2*time.Second {
+		t.Errorf("violations=%+v, retry=%v", got.Violations, got.RetryDelay)
+	}
+	if got.Err != nil || got.Stack != nil || got.Info != nil {
+		t.Error("debug info should not be sent by default")
+	}
+
+	// 未注册的编码同样保留.
+	remote := &AppError{Code: ErrorType{Code: "E999", Name: "远程错误", GRPCCode: codes.Aborted}, Desc: "x"}
+	if got := FromError(roundTrip(t, remote)); got.Code.Code != "E999" || got.Code.Name != "远程错误" || got.Code.GRPCCode != codes.Aborted {
+		t.Errorf("unregistered=%+v", got.Code)
+	}
+
+	// 其他服务的 status.
+	plain := FromError(status.Error(codes.Unavailable, "down"))
+	if plain.Code.Code != EUnknown || plain.Code.GRPCCode != codes.Unavailable || plain.Desc != "down" || status.Code(plain.Err) != codes.Unavailable {
+		t.Errorf("plain=%+v", plain)
+	}
+	if FromError(nil) != nil || FromError(io.EOF).Err != io.EOF || FromError(e) != e {
+		t.Error("unexpected FromError result")
+	}
+}
+
+func TestDebugDetails(t *testing.T) {
+	stubEmail(t)
+	DebugDetails = true
+	defer func() { DebugDetails = false }()
+	got := FromError(roundTrip(t, MemoryError("alloc", io.ErrShortBuffer, nil)))
+	if got.Err == nil || got.Err.Error() != io.ErrShortBuffer.Error() || !strings.Contains(string(got.Stack), "TestDebugDetails") {
+		t.Errorf("err=%v, stack=%s", got.Err, got.Stack)
+	}
+	got = FromError(roundTrip(t, SomethingInvalid("age", -1)))
+	if info, _ := got.Info.(map[string]interface{}); info["age"] != float64(-1) {
+		t.Errorf("info=%#v", got.Info)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"runtime/debug"
+	"time"
 )
 
 // ==========================
@@ -27,6 +28,9 @@ func New(code, detail string, err error, info interface{}) *AppError {
 		c = codeTypeMap[EUnknown]
 	}
 	e := &AppError{Code: c, Desc: detail, Err: err, Info: info}
+	if DebugDetails {
+		e.Stack = debug.Stack()
+	}
 	if c.Email {
 		go email.SendServerMail("app error email", fmt.Sprintf("%s\n\ntraceback: \n%s", e.Detail(), string(debug.Stack())), "text/plain")
 	}
@@ -38,6 +42,13 @@ type ErrorType struct {
 	Name     string
 	GRPCCode codes.Code
 	Email    bool
+
+	// 拦截器记录日志的等级(debug, info, warn, error 等), 为空时见 ErrorType.Level.
+	LogLevel string
+
+	// 客户端是否可以重试, 见 UnaryClientInterceptor. Backoff 为 nil 时使用 RetryOptions.Backoff.
+	Retryable bool
+	Backoff   *BackoffPolicy
 }
 
 func (t *ErrorType) String() string {
@@ -51,6 +62,9 @@ func RegisterError(item ErrorType) {
 		panic("自定义异常编码 " + item.Code + " 重复: " + errType.String())
 	}
 	codeTypeMap[item.Code] = item
+	if s, ok := sentinels[item.Code]; ok {
+		s.Code = item
+	}
 }
 
 type AppError struct {
@@ -59,6 +73,10 @@ type AppError struct {
 	Desc string      // 错误描述, 用户填写
 	Err  error       // 可能由其他 error 引起.
 	Info interface{} // 详细信息, 可以依据错误代码来识别具体格式.
+
+	Violations []FieldViolation // 无效的请求参数, 以 BadRequest 返回给客户端
+	RetryDelay time.Duration    // 大于 0 时以 RetryInfo 返回, 建议客户端重试的间隔
+	Stack      []byte           // 调用栈, DebugDetails 为 true 时记录, 以 DebugInfo 返回
 }
 
 func (e *AppError) Error() string {
@@ -78,9 +96,15 @@ func (e *AppError) Detail() string {
 	return fmt.Sprintf("code: %s \n\t desc: %s \n\t err: %s \n\t info: %v", e.Code.String(), e.Desc, e.Err, e.Info)
 }
 
+// GRPCStatus 返回附带 details 的 status, 见 details.go. 客户端使用 FromError 还原.
 func (e *AppError) GRPCStatus() *status.Status {
 	if e == nil {
 		return nil
 	}
-	return status.Newf(e.Code.GRPCCode, e.Desc)
+	st := status.New(e.Code.GRPCCode, e.Desc)
+	if ds, err := st.WithDetails(e.details()...); err == nil {
+		return ds
+	}
+	// codes.OK 不能附带 details.
+	return st
 }
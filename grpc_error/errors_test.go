@@ -14,6 +14,12 @@ func TestRegisterError(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			// 编码已被 general.go 注册, 重复注册应当 panic.
+			defer func() {
+				if recover() == nil {
+					t.Errorf("duplicate code %s should panic", tt.args.item.Code)
+				}
+			}()
 			RegisterError(tt.args.item)
 		})
 	}
@@ -23,7 +23,9 @@ const (
 	EPkg = "E110"
 
 	// E4: 业务错误, 用户原因
-	ERequest = "E400"
+	ERequest  = "E400"
+	ECanceled = "E401" // 客户端取消请求
+	EDeadline = "E402" // 请求超时
 
 	// E5: 代码错误
 	EInternal = "E500"
@@ -36,10 +38,12 @@ func init() {
 		{Code: EGRPC, Name: "GRPC异常", GRPCCode: codes.Internal, Email: true},
 		{Code: EMemory, Name: "内存操作异常", GRPCCode: codes.Internal, Email: true},
 		{Code: EDisk, Name: "文件系统异常", GRPCCode: codes.Internal, Email: true},
-		{Code: ENetwork, Name: "网络异常", GRPCCode: codes.Internal, Email: true},
-		{Code: EMiddleware, Name: "中间件异常", GRPCCode: codes.Internal, Email: true},
+		{Code: ENetwork, Name: "网络异常", GRPCCode: codes.Internal, Email: true, Retryable: true},
+		{Code: EMiddleware, Name: "中间件异常", GRPCCode: codes.Internal, Email: true, Retryable: true},
 		{Code: EPkg, Name: "库异常", GRPCCode: codes.Internal},
 		{Code: ERequest, Name: "请求异常", GRPCCode: codes.InvalidArgument},
+		{Code: ECanceled, Name: "请求取消", GRPCCode: codes.Canceled, LogLevel: "info"},
+		{Code: EDeadline, Name: "请求超时", GRPCCode: codes.DeadlineExceeded, LogLevel: "warn"},
 		{Code: EInternal, Name: "内部错误", GRPCCode: codes.Internal},
 
 		//{Code: ERedisServer, Name: "Redis服务器错误", GRPCCode: codes.Internal},
@@ -103,14 +107,22 @@ func SomethingInvalid(kvPair ...interface{}) *AppError {
 		name = kvPair[0].(string)
 	}
 	args := map[string]interface{}{}
+	violations := make([]FieldViolation, 0, l/2)
 	for i := 0; i+1 < l; i += 2 {
-		args[kvPair[i].(string)] = kvPair[i+1]
+		key := kvPair[i].(string)
+		args[key] = kvPair[i+1]
+		// Description 使用固定文本, 不包含请求中的值, 值保存在 Info 中.
+		violations = append(violations, FieldViolation{Field: key, Description: "invalid"})
 	}
-	return RequestError(name+" invalid", nil, args)
+	e := RequestError(name+" invalid", nil, args)
+	e.Violations = violations
+	return e
 }
 
 func SomethingRequired(key string) *AppError {
-	return RequestError(key+" required", nil, nil)
+	e := RequestError(key+" required", nil, nil)
+	e.Violations = []FieldViolation{{Field: key, Description: "required"}}
+	return e
 }
 
 func GRPCRecvError(err error) *AppError {
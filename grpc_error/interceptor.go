@@ -0,0 +1,143 @@
+package grpc_error
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/zero-miao/go-utils/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-20 02:30
+// @File   : interceptor.go
+// @Project: utils/grpc_error
+// ==========================
+
+// Level 返回拦截器记录该类型错误的日志等级: LogLevel 有效时使用 LogLevel;
+// 否则 Email 为 true 时为 error, 其他按 GRPCCode(见 logger.GRPCCodeLevel, 如 InvalidArgument 为 warn, Internal 为 error).
+func (t ErrorType) Level() zapcore.Level {
+	if level, ok := logger.LogLevelMap[t.LogLevel]; ok {
+		return level
+	}
+	if t.Email {
+		return zapcore.ErrorLevel
+	}
+	return logger.GRPCCodeLevel(t.GRPCCode)
+}
+
+// Convert 把 handler 返回的 error 转换为 AppError:
+//
+//	AppError(包括 cause 链中的)  不变
+//	context.Canceled            ECanceled, 状态码为 Canceled 的 status 同样
+//	context.DeadlineExceeded    EDeadline, 状态码为 DeadlineExceeded 的 status 同样
+//	grpc status(如下游服务返回) FromError, 保留状态码和 details
+//	其他                        EUnknown, 不会发送邮件
+func Convert(err error) *AppError {
+	if err == nil {
+		return nil
+	}
+	if e, ok := AsAppError(err); ok {
+		return e
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return New(ECanceled, err.Error(), err, nil)
+	case errors.Is(err, context.DeadlineExceeded):
+		return New(EDeadline, err.Error(), err, nil)
+	}
+	if _, ok := status.FromError(err); ok {
+		e := FromError(err)
+		if e.Code.Code == EUnknown {
+			switch e.Code.GRPCCode {
+			case codes.Canceled:
+				return New(ECanceled, e.Desc, err, nil)
+			case codes.DeadlineExceeded:
+				return New(EDeadline, e.Desc, err, nil)
+			}
+		}
+		return e
+	}
+	e := &AppError{Code: codeTypeMap[EUnknown], Desc: err.Error(), Err: err}
+	if DebugDetails {
+		e.Stack = debug.Stack()
+	}
+	return e
+}
+
+// recovered 把 recover 得到的值转换为 EInternal, 附带 panic 时的调用栈.
+func recovered(p interface{}) *AppError {
+	err, ok := p.(error)
+	if !ok {
+		err = fmt.Errorf("%v", p)
+	}
+	e := New(EInternal, "panic", fmt.Errorf("panic: %w", err), nil)
+	e.Stack = debug.Stack()
+	return e
+}
+
+// errorLogger 把 AppError 写入 module, module 为空时不记录.
+type errorLogger struct {
+	module string
+}
+
+func (l errorLogger) log(ctx context.Context, method string, e *AppError) {
+	if l.module == "" {
+		return
+	}
+	ce := logger.Ctx(ctx, l.module).Check(e.Code.Level(), "grpc "+method+": "+e.Code.String())
+	if ce == nil {
+		return
+	}
+	if len(e.Stack) > 0 {
+		ce.Stack = string(e.Stack)
+	}
+	ce.Write(zap.String("grpc.method", method), logger.Err(e))
+}
+
+// UnaryServerInterceptor handler 的 panic 转换为 EInternal, error 按 Convert 转换为 AppError, 并按 ErrorType.Level 写入 module.
+// module 为空时只转换, 不记录日志.
+func UnaryServerInterceptor(module string) grpc.UnaryServerInterceptor {
+	l := errorLogger{module: module}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				e := recovered(p)
+				l.log(ctx, info.FullMethod, e)
+				resp, err = nil, e
+			}
+		}()
+		resp, err = handler(ctx, req)
+		if e := Convert(err); e != nil {
+			l.log(ctx, info.FullMethod, e)
+			return resp, e
+		}
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor 同 UnaryServerInterceptor.
+func StreamServerInterceptor(module string) grpc.StreamServerInterceptor {
+	l := errorLogger{module: module}
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				e := recovered(p)
+				l.log(ss.Context(), info.FullMethod, e)
+				err = e
+			}
+		}()
+		if e := Convert(handler(srv, ss)); e != nil {
+			l.log(ss.Context(), info.FullMethod, e)
+			return e
+		}
+		return nil
+	}
+}
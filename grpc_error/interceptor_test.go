@@ -0,0 +1,177 @@
+package grpc_error
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zero-miao/go-utils/logger"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// testHandler 测试服务的处理函数, 请求和响应均为 StringValue.
+type testHandler func(ctx context.Context, req string) (string, error)
+
+// startTestServer 启动 bufconn 上的测试服务 test.Test, 提供 unary 方法 Call 和 server stream 方法 Stream(返回一条消息).
+func startTestServer(t *testing.T, handler testHandler, serverOpts []grpc.ServerOption, dialOpts ...grpc.DialOption) *grpc.ClientConn {
+	call := func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		req := &wrapperspb.StringValue{}
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		h := func(ctx context.Context, req interface{}) (interface{}, error) {
+			resp, err := handler(ctx, req.(*wrapperspb.StringValue).Value)
+			if err != nil {
+				return nil, err
+			}
+			return wrapperspb.String(resp), nil
+		}
+		if interceptor == nil {
+			return h(ctx, req)
+		}
+		return interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/test.Test/Call"}, h)
+	}
+	stream := func(srv interface{}, ss grpc.ServerStream) error {
+		req := &wrapperspb.StringValue{}
+		if err := ss.RecvMsg(req); err != nil {
+			return err
+		}
+		resp, err := handler(ss.Context(), req.Value)
+		if err != nil {
+			return err
+		}
+		return ss.SendMsg(wrapperspb.String(resp))
+	}
+	s := grpc.NewServer(serverOpts...)
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.Test",
+		HandlerType: (*interface{})(nil),
+		Methods:     []grpc.MethodDesc{{MethodName: "Call", Handler: call}},
+		Streams:     []grpc.StreamDesc{{StreamName: "Stream", Handler: stream, ServerStreams: true}},
+	}, struct{}{})
+	lis := bufconn.Listen(1 << 20)
+	go s.Serve(lis)
+	dialOpts = append(dialOpts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	conn, err := grpc.NewClient("passthrough:///bufnet", dialOpts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		s.Stop()
+	})
+	return conn
+}
+
+func invoke(ctx context.Context, conn *grpc.ClientConn, req string, opts ...grpc.CallOption) (string, error) {
+	resp := &wrapperspb.StringValue{}
+	err := conn.Invoke(ctx, "/test.Test/Call", wrapperspb.String(req), resp, opts...)
+	return resp.Value, err
+}
+
+func TestServerInterceptor(t *testing.T) {
+	stubEmail(t)
+	logger.YamlInit([]byte(`logging:
+  grpc_error:
+    handler:
+      - typ: memory
+        level: debug`))
+	handler := func(ctx context.Context, req string) (string, error) {
+		switch req {
+		case "panic":
+			var m map[string]int
+			m["x"] = 1
+		case "plain":
+			return "", io.ErrUnexpectedEOF
+		case "request":
+			return "", SomethingRequired("name")
+		case "deadline":
+			<-ctx.Done()
+			return "", ctx.Err()
+		case "downstream":
+			return "", status.Error(codes.DeadlineExceeded, "downstream timeout")
+		}
+		return "ok:" + req, nil
+	}
+	conn := startTestServer(t, handler, []grpc.ServerOption{
+		grpc.UnaryInterceptor(UnaryServerInterceptor("grpc_error")),
+		grpc.StreamInterceptor(StreamServerInterceptor("grpc_error")),
+	})
+	ctx := context.Background()
+	mem := logger.Memory("grpc_error")
+
+	if resp, err := invoke(ctx, conn, "hi"); err != nil || resp != "ok:hi" {
+		t.Fatalf("resp=%q, err=%v", resp, err)
+	}
+	if mem.Len() != 0 {
+		t.Errorf("success logged: %+v", mem.Entries())
+	}
+
+	_, err := invoke(ctx, conn, "panic")
+	if e := FromError(err); !errors.Is(e, ErrInternal) || e.Code.GRPCCode != codes.Internal {
+		t.Errorf("panic err=%v", err)
+	}
+	ent := mem.Last(1)[0]
+	if ent.Level != zapcore.ErrorLevel || !strings.Contains(ent.Stack, "TestServerInterceptor") || ent.Fields["grpc.method"] != "/test.Test/Call" {
+		t.Errorf("panic entry=%+v", ent)
+	}
+
+	_, err = invoke(ctx, conn, "plain")
+	if e := FromError(err); !errors.Is(e, ErrUnknown) || e.Desc != io.ErrUnexpectedEOF.Error() {
+		t.Errorf("plain err=%v", err)
+	}
+
+	_, err = invoke(ctx, conn, "request")
+	if e := FromError(err); !errors.Is(e, ErrRequest) || len(e.Violations) != 1 {
+		t.Errorf("request err=%v", err)
+	}
+	if ent := mem.Last(1)[0]; ent.Level != zapcore.WarnLevel {
+		t.Errorf("request entry=%+v", ent)
+	}
+
+	dctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err = invoke(dctx, conn, "deadline"); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("deadline err=%v", err)
+	}
+	_, err = invoke(ctx, conn, "downstream")
+	if e := FromError(err); !errors.Is(e, ErrDeadline) || e.Code.GRPCCode != codes.DeadlineExceeded {
+		t.Errorf("downstream err=%v", err)
+	}
+
+	// 服务端在 handler 返回后才记录超时, 等待日志写入.
+	// 客户端超时后服务端可能先收到取消(ECanceled), 也可能先到达 deadline(EDeadline).
+	deadline := time.Now().Add(2 * time.Second)
+	for len(mem.ByField("grpc.method", "/test.Test/Call")) < 5 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	timeout := mem.Filter(func(e logger.MemoryEntry) bool {
+		return strings.Contains(e.Message, ECanceled) || strings.Contains(e.Message, EDeadline) && e.Level == zapcore.WarnLevel
+	})
+	if len(timeout) != 2 {
+		t.Errorf("timeout entries=%+v", mem.Entries())
+	}
+
+	s, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, "/test.Test/Stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SendMsg(wrapperspb.String("panic"))
+	s.CloseSend()
+	if err := s.RecvMsg(&wrapperspb.StringValue{}); !errors.Is(FromError(err), ErrInternal) {
+		t.Errorf("stream panic err=%v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package grpc_error
+
+import "go.uber.org/zap/zapcore"
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 18:10
+// @File   : log.go
+// @Project: utils/grpc_error
+// ==========================
+
+// MarshalLogObject 实现 zapcore.ObjectMarshaler, 配合 logger.ErrorChain 以结构化字段输出.
+// cause(Err) 由 ErrorChain 展开, 这里不重复输出.
+func (e *AppError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if e == nil {
+		return nil
+	}
+	enc.AddString("code", e.Code.Code)
+	enc.AddString("name", e.Code.Name)
+	enc.AddString("grpc_code", e.Code.GRPCCode.String())
+	enc.AddString("desc", e.Desc)
+	if e.RetryDelay > 0 {
+		enc.AddDuration("retry_delay", e.RetryDelay)
+	}
+	if len(e.Violations) > 0 {
+		if err := enc.AddReflected("violations", e.Violations); err != nil {
+			return err
+		}
+	}
+	if e.Info != nil {
+		return enc.AddReflected("info", e.Info)
+	}
+	return nil
+}
+
+// Cause 返回引起该错误的 error, 供 logger.ErrorChain 等按 Cause() 约定展开 cause 链.
+func (e *AppError) Cause() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
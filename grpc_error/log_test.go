@@ -0,0 +1,22 @@
+package grpc_error
+
+import (
+	"io"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestMarshalLogObject(t *testing.T) {
+	enc := zapcore.NewMapObjectEncoder()
+	e := RequestError("bad", io.EOF, map[string]int{"id": 1})
+	if err := e.MarshalLogObject(enc); err != nil {
+		t.Fatal(err)
+	}
+	if enc.Fields["code"] != ERequest || enc.Fields["grpc_code"] != "InvalidArgument" || enc.Fields["desc"] != "bad" || enc.Fields["info"] == nil {
+		t.Errorf("fields=%+v", enc.Fields)
+	}
+	if e.Cause() != io.EOF {
+		t.Error("Cause() mismatch")
+	}
+}
@@ -0,0 +1,278 @@
+package grpc_error
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-20 03:00
+// @File   : retry.go
+// @Project: utils/grpc_error
+// ==========================
+
+// BackoffPolicy 重试间隔: 第 n 次重试前等待 Initial * Multiplier^(n-1), 不超过 Max, 再按 Jitter 随机浮动.
+type BackoffPolicy struct {
+	Initial    time.Duration // 默认 100ms
+	Max        time.Duration // 默认 5s
+	Multiplier float64       // 默认 2
+	Jitter     float64       // 0 ~ 1, 在 [d*(1-Jitter), d*(1+Jitter)] 内随机, 0 表示不浮动
+}
+
+// Delay 返回第 retry 次(从 1 开始)重试前的等待时间.
+func (p BackoffPolicy) Delay(retry int) time.Duration {
+	initial, max, multiplier := p.Initial, p.Max, p.Multiplier
+	if initial <= 0 {
+		initial = 100 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 5 * time.Second
+	}
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	d := float64(initial) * math.Pow(multiplier, float64(retry-1))
+	if d > float64(max) {
+		d = float64(max)
+	}
+	if p.Jitter > 0 {
+		d *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(d)
+}
+
+// RetryBudget 重试预算(同 grpc retryThrottling): 每个方法有 MaxTokens 个 token, 失败减 1, 成功加 TokenRatio,
+// token 不超过一半时不再重试和 hedging, 避免下游故障时重试放大请求. MaxTokens 为 0 表示不限制.
+type RetryBudget struct {
+	MaxTokens  float64
+	TokenRatio float64 // 默认 0.1
+}
+
+type budget struct {
+	cfg    RetryBudget
+	mu     sync.Mutex
+	tokens float64
+}
+
+func (b *budget) allow() bool {
+	if b.cfg.MaxTokens <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens > b.cfg.MaxTokens/2
+}
+
+func (b *budget) failure() {
+	b.mu.Lock()
+	b.tokens = math.Max(0, b.tokens-1)
+	b.mu.Unlock()
+}
+
+func (b *budget) success() {
+	ratio := b.cfg.TokenRatio
+	if ratio <= 0 {
+		ratio = 0.1
+	}
+	b.mu.Lock()
+	b.tokens = math.Min(b.cfg.MaxTokens, b.tokens+ratio)
+	b.mu.Unlock()
+}
+
+// RetryOptions 客户端重试配置.
+type RetryOptions struct {
+	MaxAttempts int           // 总尝试次数(包括第一次), 默认 3, 1 表示不重试
+	Backoff     BackoffPolicy // ErrorType.Backoff 为 nil 时使用
+	Budget      RetryBudget   // 每个方法单独计算
+
+	// 返回的错误不是 AppError(没有 ErrorInfo)时, 这些状态码可以重试, 默认 Unavailable.
+	RetryCodes []codes.Code
+
+	// 大于 0 时启用 hedging: 请求在 HedgingDelay 内没有返回时, 再并发发送一次, 最多同时额外发送 MaxHedged 次(默认 1),
+	// 总次数同样受 MaxAttempts 限制, 先返回成功或不可重试错误的结果为准. 只应用于幂等的方法.
+	HedgingDelay time.Duration
+	MaxHedged    int
+
+	// 按方法覆盖, key 为完整方法名(/pkg.Service/Method)或服务名(/pkg.Service/), 完整方法名优先.
+	Methods map[string]RetryOptions
+}
+
+func (o RetryOptions) withDefaults() RetryOptions {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+	if o.RetryCodes == nil {
+		o.RetryCodes = []codes.Code{codes.Unavailable}
+	}
+	if o.HedgingDelay > 0 && o.MaxHedged <= 0 {
+		o.MaxHedged = 1
+	}
+	return o
+}
+
+// retryDecision 返回 err 是否可以重试, 以及重试前的等待时间.
+func (o RetryOptions) retryDecision(err error, retry int) (bool, time.Duration) {
+	e := FromError(err)
+	retryable := e.Code.Retryable
+	if e.Code.Code == EUnknown {
+		for _, c := range o.RetryCodes {
+			retryable = retryable || c == e.Code.GRPCCode
+		}
+	}
+	if !retryable {
+		return false, 0
+	}
+	backoff := o.Backoff
+	if e.Code.Backoff != nil {
+		backoff = *e.Code.Backoff
+	}
+	delay := backoff.Delay(retry)
+	// 服务端通过 RetryInfo 建议的间隔.
+	if e.RetryDelay > delay {
+		delay = e.RetryDelay
+	}
+	return true, delay
+}
+
+type retrier struct {
+	opts RetryOptions
+
+	mu      sync.Mutex
+	budgets map[string]*budget
+}
+
+func (r *retrier) options(method string) RetryOptions {
+	if o, ok := r.opts.Methods[method]; ok {
+		return o.withDefaults()
+	}
+	if i := strings.LastIndex(method, "/"); i > 0 {
+		if o, ok := r.opts.Methods[method[:i+1]]; ok {
+			return o.withDefaults()
+		}
+	}
+	return r.opts.withDefaults()
+}
+
+func (r *retrier) budget(method string, cfg RetryBudget) *budget {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.budgets[method]
+	if !ok {
+		b = &budget{cfg: cfg, tokens: cfg.MaxTokens}
+		r.budgets[method] = b
+	}
+	return b
+}
+
+type attemptResult struct {
+	reply interface{}
+	err   error
+}
+
+func (r *retrier) invoke(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+	o := r.options(method)
+	if o.MaxAttempts == 1 {
+		return invoker(ctx, method, req, reply, cc, callOpts...)
+	}
+	b := r.budget(method, o.Budget)
+	// hedging 时多个请求同时进行, 每个请求使用单独的 reply, 成功后复制到 reply.
+	msg, hedging := reply.(proto.Message)
+	hedging = hedging && o.HedgingDelay > 0
+
+	actx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan attemptResult, o.MaxAttempts)
+	started, inflight, hedged := 0, 0, 0
+	start := func() {
+		started++
+		inflight++
+		out := reply
+		if hedging {
+			out = proto.Clone(msg)
+			proto.Reset(out.(proto.Message))
+		}
+		go func() {
+			err := invoker(actx, method, req, out, cc, callOpts...)
+			results <- attemptResult{reply: out, err: err}
+		}()
+	}
+
+	var hedgeTimer, retryTimer <-chan time.Time
+	resetHedge := func() {
+		hedgeTimer = nil
+		if hedging && hedged < o.MaxHedged && started < o.MaxAttempts {
+			hedgeTimer = time.After(o.HedgingDelay)
+		}
+	}
+	start()
+	resetHedge()
+	var lastErr error
+	for {
+		select {
+		case res := <-results:
+			inflight--
+			if res.err == nil {
+				b.success()
+				if hedging {
+					proto.Reset(msg)
+					proto.Merge(msg, res.reply.(proto.Message))
+				}
+				return nil
+			}
+			lastErr = res.err
+			if ctx.Err() != nil {
+				return lastErr
+			}
+			retryable, delay := o.retryDecision(res.err, started)
+			if !retryable {
+				return lastErr
+			}
+			b.failure()
+			if started < o.MaxAttempts && b.allow() && retryTimer == nil {
+				retryTimer = time.After(delay)
+			} else if inflight == 0 && retryTimer == nil {
+				return lastErr
+			}
+		case <-retryTimer:
+			retryTimer = nil
+			if started < o.MaxAttempts && b.allow() {
+				start()
+				resetHedge()
+			} else if inflight == 0 {
+				return lastErr
+			}
+		case <-hedgeTimer:
+			hedgeTimer = nil
+			if b.allow() {
+				hedged++
+				start()
+			}
+			resetHedge()
+		case <-ctx.Done():
+			if lastErr != nil {
+				return lastErr
+			}
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// UnaryClientInterceptor 按错误类型重试 unary 请求: 返回的错误按 FromError 还原, ErrorType.Retryable 为 true 时重试,
+// 不是 AppError 时按 RetryOptions.RetryCodes 判断. 重试间隔取 backoff 和服务端 RetryInfo 中较大的一个.
+// stream 请求不重试.
+func UnaryClientInterceptor(opts RetryOptions) grpc.UnaryClientInterceptor {
+	r := &retrier{opts: opts, budgets: map[string]*budget{}}
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		return r.invoke(ctx, method, req, reply, cc, invoker, callOpts...)
+	}
+}
@@ -0,0 +1,172 @@
+package grpc_error
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestBackoffPolicy(t *testing.T) {
+	p := BackoffPolicy{Initial: 10 * time.Millisecond, Max: 35 * time.Millisecond}
+	for i, want := range []time.Duration{10, 20, 35, 35} {
+		if got := p.Delay(i + 1); got != want*time.Millisecond {
+			t.Errorf("Delay(%d)=%v, want %v", i+1, got, want*time.Millisecond)
+		}
+	}
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := p.Delay(1); d < 5*time.Millisecond || d > 15*time.Millisecond {
+			t.Fatalf("jitter delay=%v", d)
+		}
+	}
+}
+
+// flakyServer 请求格式为 "kind:n:id", 相同请求的前 n 次按 kind 失败, 之后成功.
+type flakyServer struct {
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+func (s *flakyServer) count(req string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts[req]
+}
+
+func (s *flakyServer) handle(ctx context.Context, req string) (string, error) {
+	s.mu.Lock()
+	s.attempts[req]++
+	attempt := s.attempts[req]
+	s.mu.Unlock()
+	parts := strings.Split(req, ":")
+	n, _ := strconv.Atoi(parts[1])
+	if attempt > n {
+		return "ok", nil
+	}
+	switch parts[0] {
+	case "network":
+		return "", NetworkError("dial", errors.New("connection refused"), nil)
+	case "request":
+		return "", BadRequest("bad")
+	case "unavailable":
+		return "", status.Error(codes.Unavailable, "unavailable")
+	case "retry_after":
+		e := New(EMiddleware, "busy", nil, nil)
+		e.RetryDelay = 50 * time.Millisecond
+		return "", e
+	case "slow":
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+		}
+		return "slow", nil
+	}
+	return "", errors.New("unknown kind")
+}
+
+// startFlaky 启动测试服务, 除 startTestServer 的方法外, 其他方法(如 /test.Test/Other)同样由 flakyServer 处理.
+func startFlaky(t *testing.T, opts RetryOptions) (*flakyServer, *grpc.ClientConn) {
+	stubEmail(t)
+	s := &flakyServer{attempts: map[string]int{}}
+	unknown := func(srv interface{}, ss grpc.ServerStream) error {
+		req := &wrapperspb.StringValue{}
+		if err := ss.RecvMsg(req); err != nil {
+			return err
+		}
+		resp, err := s.handle(ss.Context(), req.Value)
+		if err != nil {
+			return err
+		}
+		return ss.SendMsg(wrapperspb.String(resp))
+	}
+	conn := startTestServer(t, s.handle, []grpc.ServerOption{grpc.UnknownServiceHandler(unknown)}, grpc.WithUnaryInterceptor(UnaryClientInterceptor(opts)))
+	return s, conn
+}
+
+func invokeMethod(ctx context.Context, conn *grpc.ClientConn, method, req string) (string, error) {
+	resp := &wrapperspb.StringValue{}
+	err := conn.Invoke(ctx, method, wrapperspb.String(req), resp)
+	return resp.Value, err
+}
+
+func TestRetryInterceptor(t *testing.T) {
+	s, conn := startFlaky(t, RetryOptions{
+		MaxAttempts: 4,
+		Backoff:     BackoffPolicy{Initial: time.Millisecond, Jitter: 0.2},
+		Methods:     map[string]RetryOptions{"/test.Test/Other": {MaxAttempts: 1}},
+	})
+	ctx := context.Background()
+	for _, c := range []struct {
+		req      string
+		ok       bool
+		attempts int
+	}{
+		{"network:2:a", true, 3},     // ENetwork 可以重试
+		{"network:10:b", false, 4},   // 超过 MaxAttempts
+		{"request:1:c", false, 1},    // ERequest 不重试
+		{"unavailable:1:d", true, 2}, // 不是 AppError, 按状态码
+		{"retry_after:1:e", true, 2}, // EMiddleware 可以重试
+	} {
+		start := time.Now()
+		resp, err := invoke(ctx, conn, c.req)
+		if (err == nil) != c.ok || s.count(c.req) != c.attempts {
+			t.Errorf("%s: resp=%q, err=%v, attempts=%d, want ok=%v attempts=%d", c.req, resp, err, s.count(c.req), c.ok, c.attempts)
+		}
+		if c.req == "network:10:b" && !errors.Is(FromError(err), ErrNetwork) {
+			t.Errorf("last error=%v", err)
+		}
+		if strings.HasPrefix(c.req, "retry_after") && time.Since(start) < 50*time.Millisecond {
+			t.Errorf("RetryInfo delay ignored: %v", time.Since(start))
+		}
+	}
+	if _, err := invokeMethod(ctx, conn, "/test.Test/Other", "network:1:f"); err == nil || s.count("network:1:f") != 1 {
+		t.Errorf("method override: err=%v, attempts=%d", err, s.count("network:1:f"))
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	s, conn := startFlaky(t, RetryOptions{
+		MaxAttempts: 5,
+		Backoff:     BackoffPolicy{Initial: time.Millisecond},
+		Budget:      RetryBudget{MaxTokens: 4},
+	})
+	ctx := context.Background()
+	// token: 4 -> 3(可以重试) -> 2(不超过一半, 停止)
+	invoke(ctx, conn, "network:100:a")
+	if n := s.count("network:100:a"); n != 2 {
+		t.Errorf("attempts=%d, want 2", n)
+	}
+	invoke(ctx, conn, "network:100:b")
+	if n := s.count("network:100:b"); n != 1 {
+		t.Errorf("attempts=%d, want 1 after budget exhausted", n)
+	}
+	// 每个方法单独计算.
+	invokeMethod(ctx, conn, "/test.Test/Other", "network:100:c")
+	if n := s.count("network:100:c"); n != 2 {
+		t.Errorf("other method attempts=%d, want 2", n)
+	}
+}
+
+func TestHedging(t *testing.T) {
+	s, conn := startFlaky(t, RetryOptions{MaxAttempts: 3, HedgingDelay: 20 * time.Millisecond})
+	start := time.Now()
+	resp, err := invoke(context.Background(), conn, "slow:1:a")
+	if err != nil || resp != "ok" {
+		t.Fatalf("resp=%q, err=%v", resp, err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("hedged request took %v", d)
+	}
+	if n := s.count("slow:1:a"); n != 2 {
+		t.Errorf("attempts=%d, want 2", n)
+	}
+}
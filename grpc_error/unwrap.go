@@ -0,0 +1,78 @@
+package grpc_error
+
+import "errors"
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-20 01:40
+// @File   : unwrap.go
+// @Project: utils/grpc_error
+// ==========================
+
+// sentinels 各错误编码的哨兵值, 用于 errors.Is(err, ErrRequest).
+var sentinels = map[string]*AppError{}
+
+// Sentinel 返回错误编码对应的哨兵值, errors.Is(err, Sentinel(code)) 判断 err 的 cause 链中是否有该编码的 AppError.
+// 自定义编码可以在 RegisterError 之前或之后获取, 注册后 Code 中的名称等信息会被更新.
+func Sentinel(code string) *AppError {
+	if s, ok := sentinels[code]; ok {
+		return s
+	}
+	t, ok := codeTypeMap[code]
+	if !ok {
+		t = ErrorType{Code: code}
+	}
+	s := &AppError{Code: t}
+	sentinels[code] = s
+	return s
+}
+
+var (
+	ErrUnknown     = Sentinel(EUnknown)
+	ErrGRPC        = Sentinel(EGRPC)
+	ErrMemory      = Sentinel(EMemory)
+	ErrDisk        = Sentinel(EDisk)
+	ErrNetwork     = Sentinel(ENetwork)
+	ErrMiddleware  = Sentinel(EMiddleware)
+	ErrPkg         = Sentinel(EPkg)
+	ErrRequest     = Sentinel(ERequest)
+	ErrCanceled    = Sentinel(ECanceled)
+	ErrDeadline    = Sentinel(EDeadline)
+	ErrInternal    = Sentinel(EInternal)
+	ErrRedisServer = Sentinel(ERedisServer)
+	ErrRedisClient = Sentinel(ERedisClient)
+)
+
+// Unwrap 返回引起该错误的 error, 使 errors.Is(err, io.EOF) 等可以匹配 cause.
+func (e *AppError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// Is 错误编码(Code.Code)相同即视为相同, 不比较 Desc, Info 和 cause.
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	if !ok || e == nil || t == nil {
+		return false
+	}
+	return t.Code.Code != "" && e.Code.Code == t.Code.Code
+}
+
+// AsAppError 返回 err 的 cause 链中第一个 AppError.
+func AsAppError(err error) (*AppError, bool) {
+	var e *AppError
+	if errors.As(err, &e) && e != nil {
+		return e, true
+	}
+	return nil, false
+}
+
+// CodeOf 返回 err 的 cause 链中第一个 AppError 的错误编码, 没有时返回空字符串.
+func CodeOf(err error) string {
+	if e, ok := AsAppError(err); ok {
+		return e.Code.Code
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package grpc_error
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/zero-miao/go-utils/email"
+	"google.golang.org/grpc/codes"
+)
+
+// stubEmail 让 Email 为 true 的错误可以在测试中创建: 没有收件人时 SendServerMail 直接返回错误.
+// 邮件在协程中异步发送, 测试结束时可能仍在读取配置, 因此不恢复.
+func stubEmail(t *testing.T) {
+	t.Helper()
+	if email.GeneralConfig == nil {
+		email.GeneralConfig = &email.Config{}
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	stubEmail(t)
+	err := fmt.Errorf("handler: %w", NetworkError("read", io.EOF, nil))
+	if !errors.Is(err, io.EOF) {
+		t.Error("errors.Is(err, io.EOF) = false")
+	}
+	if !errors.Is(err, ErrNetwork) || errors.Is(err, ErrRequest) {
+		t.Error("sentinel mismatch")
+	}
+	if !errors.Is(BadRequest("x"), ErrRequest) || errors.Is(BadRequest("x"), ErrNetwork) {
+		t.Error("request sentinel mismatch")
+	}
+	if ErrNetwork.Code.Name != "网络异常" || ErrNetwork.Code.GRPCCode != codes.Internal {
+		t.Errorf("sentinel type not filled: %+v", ErrNetwork.Code)
+	}
+
+	e, ok := AsAppError(err)
+	if !ok || e.Desc != "read" || CodeOf(err) != ENetwork {
+		t.Errorf("AsAppError=%v, %v", e, ok)
+	}
+	if _, ok := AsAppError(io.EOF); ok || CodeOf(io.EOF) != "" {
+		t.Error("plain error is not AppError")
+	}
+
+	// 嵌套的 AppError, 外层和内层的编码都能匹配.
+	nested := RequestError("bad", MemoryError("alloc", io.ErrShortBuffer, nil), nil)
+	if !errors.Is(nested, ErrRequest) || !errors.Is(nested, ErrMemory) || !errors.Is(nested, io.ErrShortBuffer) {
+		t.Error("nested chain mismatch")
+	}
+}
+
+func TestSentinelCustomCode(t *testing.T) {
+	const ETest = "E901"
+	s := Sentinel(ETest)
+	RegisterError(ErrorType{Code: ETest, Name: "测试", GRPCCode: codes.Aborted})
+	t.Cleanup(func() {
+		delete(codeTypeMap, ETest)
+		delete(sentinels, ETest)
+	})
+	if s != Sentinel(ETest) || s.Code.Name != "测试" {
+		t.Errorf("sentinel=%+v", s)
+	}
+	if !errors.Is(New(ETest, "x", nil, nil), s) {
+		t.Error("custom code not matched")
+	}
+}
@@ -0,0 +1,256 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"text/template"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 23:10
+// @File   : access.go
+// @Project: utils/logger
+// ==========================
+
+// access log 格式
+const (
+	AccessFormatFields   = "fields"   // 日志内容为 "METHOD path", 其他信息作为字段(默认)
+	AccessFormatCombined = "combined" // apache combined 格式, 整行作为日志内容
+)
+
+// AccessLogOptions http access log 的配置.
+type AccessLogOptions struct {
+	Module string // 写入的 logger module
+
+	// fields | combined | text/template 模板, 模板的参数为 *AccessRecord, 如 `{{.Method}} {{.URI}} {{.Status}} {{.Latency}}`
+	Format string
+
+	// 请求 id 所在的 header, 默认 X-Request-Id. 请求中没有或者无效(见 validRequestID)时生成一个, 同时写入响应 header 和 ctx(WithRequestID).
+	RequestIDHeader string
+}
+
+// AccessRecord 一次请求的信息.
+type AccessRecord struct {
+	Time       time.Time // 请求开始时间
+	RemoteAddr string
+	User       string // basic auth 的用户名
+	Method     string
+	URI        string
+	Proto      string
+	Host       string
+	Status     int
+	Bytes      int64 // 响应 body 的字节数
+	Latency    time.Duration
+	Referer    string
+	UserAgent  string
+	RequestID  string
+}
+
+// Combined 返回 apache combined 格式的一行.
+func (r *AccessRecord) Combined() string {
+	user := r.User
+	if user == "" {
+		user = "-"
+	}
+	size := "-"
+	if r.Bytes > 0 {
+		size = strconv.FormatInt(r.Bytes, 10)
+	}
+	return fmt.Sprintf("%s - %s [%s] %q %d %s %q %q",
+		r.RemoteAddr, user, r.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method+" "+r.URI+" "+r.Proto, r.Status, size, r.Referer, r.UserAgent)
+}
+
+// AccessLevel 返回响应状态码对应的日志等级: 5xx 为 error, 4xx 为 warn, 其他为 info.
+func AccessLevel(status int) zapcore.Level {
+	switch {
+	case status >= 500:
+		return zapcore.ErrorLevel
+	case status >= 400:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+// AccessLog 返回记录 access log 的 http 中间件, Format 为无效模板时 panic.
+func AccessLog(opts AccessLogOptions) func(http.Handler) http.Handler {
+	header := opts.RequestIDHeader
+	if header == "" {
+		header = "X-Request-Id"
+	}
+	var tpl *template.Template
+	switch opts.Format {
+	case "", AccessFormatFields, AccessFormatCombined:
+	default:
+		var err error
+		if tpl, err = template.New("access").Parse(opts.Format); err != nil {
+			panic("access log format: " + err.Error())
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			id := r.Header.Get(header)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			w.Header().Set(header, id)
+			ctx := WithRequestID(r.Context(), id)
+			rw := &accessWriter{ResponseWriter: w}
+			// handler panic 时同样记录(状态码为 500), 之后继续 panic.
+			defer func() {
+				p := recover()
+				if p != nil {
+					rw.status = http.StatusInternalServerError
+				} else if rw.status == 0 {
+					rw.status = http.StatusOK
+				}
+				user, _, _ := r.BasicAuth()
+				rec := &AccessRecord{
+					Time:       start,
+					RemoteAddr: remoteHost(r.RemoteAddr),
+					User:       user,
+					Method:     r.Method,
+					URI:        r.RequestURI,
+					Proto:      r.Proto,
+					Host:       r.Host,
+					Status:     rw.status,
+					Bytes:      rw.bytes,
+					Latency:    time.Since(start),
+					Referer:    r.Referer(),
+					UserAgent:  r.UserAgent(),
+					RequestID:  id,
+				}
+				if rec.URI == "" {
+					rec.URI = r.URL.RequestURI()
+				}
+				var msg string
+				var fields []zap.Field
+				switch {
+				case tpl != nil:
+					var buf bytes.Buffer
+					if err := tpl.Execute(&buf, rec); err != nil {
+						msg = "access log format: " + err.Error()
+					} else {
+						msg = buf.String()
+					}
+				case opts.Format == AccessFormatCombined:
+					msg = rec.Combined()
+				default:
+					msg = rec.Method + " " + r.URL.Path
+					fields = []zap.Field{
+						zap.String("http.method", rec.Method),
+						zap.String("http.uri", rec.URI),
+						zap.String("http.proto", rec.Proto),
+						zap.String("http.host", rec.Host),
+						zap.Int("http.status", rec.Status),
+						zap.Int64("http.bytes", rec.Bytes),
+						zap.Duration("http.latency", rec.Latency),
+						zap.String("http.remote_addr", rec.RemoteAddr),
+						zap.String("http.referer", rec.Referer),
+						zap.String("http.user_agent", rec.UserAgent),
+					}
+				}
+				if ce := Ctx(ctx, opts.Module).Check(AccessLevel(rec.Status), msg); ce != nil {
+					ce.Write(fields...)
+				}
+				if p != nil {
+					panic(p)
+				}
+			}()
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// maxRequestIDLen 请求中的 request id 的最大长度.
+const maxRequestIDLen = 128
+
+// validRequestID 请求中的 request id 会写入日志和响应 header, 只接受长度不超过 maxRequestIDLen,
+// 由字母, 数字和 -_.: 组成的值, 防止伪造日志内容或写入过长的值.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func remoteHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// accessWriter 记录响应的状态码和字节数.
+type accessWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *accessWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *accessWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *accessWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack 用于 websocket 等, 之后的数据不计入 bytes.
+func (w *accessWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		if w.status == 0 {
+			w.status = http.StatusSwitchingProtocols
+		}
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("hijack not supported")
+}
+
+// Unwrap 供 http.ResponseController 使用.
+func (w *accessWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAccessLogFields(t *testing.T) {
+	YamlInit([]byte(`logging:
+  access:
+    handler:
+      - typ: memory
+        level: debug`))
+	var downstream string
+	h := AccessLog(AccessLogOptions{Module: "access"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downstream = RequestID(r.Context())
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "missing")
+	}))
+	req := httptest.NewRequest("GET", "/users/1?x=1", nil)
+	req.Header.Set("X-Request-Id", "req-9")
+	req.Header.Set("User-Agent", "test")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if downstream != "req-9" || rec.Header().Get("X-Request-Id") != "req-9" {
+		t.Errorf("request id not propagated: %q %q", downstream, rec.Header().Get("X-Request-Id"))
+	}
+	res := Memory("access").Entries()
+	if len(res) != 1 {
+		t.Fatalf("entries=%+v", res)
+	}
+	ent := res[0]
+	if ent.Message != "GET /users/1" || ent.Level != zapcore.WarnLevel || ent.Fields[FieldRequestID] != "req-9" {
+		t.Errorf("entry=%+v", ent)
+	}
+	for key, want := range map[string]string{"http.status": "404", "http.bytes": "7", "http.uri": "/users/1?x=1", "http.user_agent": "test"} {
+		if v, _ := ent.Field(key); v != want {
+			t.Errorf("%s=%s, want %s", key, v, want)
+		}
+	}
+	if ent.Fields["http.latency"] == nil {
+		t.Error("latency missing")
+	}
+}
+
+func TestAccessLogInvalidRequestID(t *testing.T) {
+	YamlInit([]byte(`logging:
+  access_id:
+    handler:
+      - typ: memory
+        level: debug`))
+	var downstream string
+	h := AccessLog(AccessLogOptions{Module: "access_id"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downstream = RequestID(r.Context())
+	}))
+	for _, id := range []string{"a b", "x\r\ny", "<script>", strings.Repeat("a", maxRequestIDLen+1)} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Request-Id", id)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if downstream == id || len(downstream) != 32 || rec.Header().Get("X-Request-Id") != downstream {
+			t.Errorf("id %q not replaced: %q", id, downstream)
+		}
+	}
+	if !validRequestID("req-9") || !validRequestID("0af7651916cd43dd8448eb211c80319c") || !validRequestID(strings.Repeat("a", maxRequestIDLen)) {
+		t.Error("valid request id rejected")
+	}
+}
+
+func TestAccessLogFormats(t *testing.T) {
+	YamlInit([]byte(`logging:
+  access_fmt:
+    handler:
+      - typ: memory
+        level: debug`))
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "hello") })
+
+	AccessLog(AccessLogOptions{Module: "access_fmt", Format: AccessFormatCombined})(ok).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/a", nil))
+	AccessLog(AccessLogOptions{Module: "access_fmt", Format: "{{.Method}} {{.URI}} {{.Status}} {{.Bytes}}", RequestIDHeader: "X-Trace"})(ok).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/b", nil))
+
+	res := Memory("access_fmt").Entries()
+	if len(res) != 2 {
+		t.Fatalf("entries=%+v", res)
+	}
+	if !strings.HasPrefix(res[0].Message, "192.0.2.1 - - [") || !strings.HasSuffix(res[0].Message, `"POST /a HTTP/1.1" 200 5 "" ""`) {
+		t.Errorf("combined=%q", res[0].Message)
+	}
+	if res[1].Message != "GET /b 200 5" || res[1].Fields[FieldRequestID] == "" {
+		t.Errorf("template=%+v", res[1])
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("invalid template should panic")
+		}
+	}()
+	AccessLog(AccessLogOptions{Format: "{{.Method"})
+}
+
+func TestAccessLogPanic(t *testing.T) {
+	YamlInit([]byte(`logging:
+  access_panic:
+    handler:
+      - typ: memory
+        level: debug`))
+	h := AccessLog(AccessLogOptions{Module: "access_panic"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("panic=%v, want re-panic", p)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	}()
+
+	res := Memory("access_panic").Entries()
+	if len(res) != 1 || res[0].Level != zapcore.ErrorLevel {
+		t.Fatalf("entries=%+v", res)
+	}
+	if v, _ := res[0].Field("http.status"); v != "500" {
+		t.Errorf("http.status=%s", v)
+	}
+}
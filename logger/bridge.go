@@ -0,0 +1,264 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/grpclog"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 22:10
+// @File   : bridge.go
+// @Project: utils/logger
+// ==========================
+
+// RedirectStdLog 把标准库 log 的输出以 level 等级写入 module, 返回恢复原来输出的函数.
+// 使用调用时 module 的 logger, 重新调用 YamlInit 后需要重新调用.
+func RedirectStdLog(module string, level zapcore.Level) (func(), error) {
+	return zap.RedirectStdLogAt(L(module), level)
+}
+
+// lazyLogger 在使用时获取 module 的 logger, YamlInit 重新初始化后重新获取, 用于长期持有 logger 的 bridge.
+type lazyLogger struct {
+	module string
+	wrap   func(*zap.Logger) *zap.Logger
+	cache  atomic.Pointer[lazyEntry]
+}
+
+type lazyEntry struct {
+	gen    uint64
+	logger *zap.Logger
+}
+
+func newLazyLogger(module string, wrap func(*zap.Logger) *zap.Logger) *lazyLogger {
+	return &lazyLogger{module: module, wrap: wrap}
+}
+
+func (l *lazyLogger) get() *zap.Logger {
+	gen := atomic.LoadUint64(&loggerGen)
+	if e := l.cache.Load(); e != nil && e.gen == gen {
+		return e.logger
+	}
+	e := &lazyEntry{gen: gen, logger: l.wrap(L(l.module))}
+	l.cache.Store(e)
+	return e.logger
+}
+
+// SlogHandler 把 log/slog 的日志写入 module, 同时附带 ContextFields(ctx) 中的字段.
+type SlogHandler struct {
+	module    string
+	fields    []zap.Field // WithAttrs, WithGroup 附加的字段
+	logger    *lazyLogger
+	addSource bool
+	level     slog.Leveler
+}
+
+// NewSlogHandler 创建 module 对应的 slog.Handler, opts 中只有 AddSource 和 Level 生效.
+// 日志经过 module 的 logger, stacktrace_level, str_field 等 module 配置同样生效;
+// 调用位置取自 slog.Record, 只由 AddSource 控制, 与 module 的 caller, caller_skip 无关.
+// logger 在写入时获取, YamlInit 重新初始化后不需要重新创建.
+//
+//	slog.SetDefault(slog.New(logger.NewSlogHandler("app", nil)))
+func NewSlogHandler(module string, opts *slog.HandlerOptions) *SlogHandler {
+	h := &SlogHandler{module: module}
+	h.logger = h.lazy()
+	if opts != nil {
+		h.addSource = opts.AddSource
+		h.level = opts.Level
+	}
+	return h
+}
+
+func (h *SlogHandler) lazy() *lazyLogger {
+	fields := h.fields
+	return newLazyLogger(h.module, func(l *zap.Logger) *zap.Logger {
+		return l.WithOptions(zap.WithCaller(false)).With(fields...)
+	})
+}
+
+// SlogLevel 把 slog 的等级转换为 zap 的等级, 介于两者之间的按较低的等级.
+func SlogLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if h.level != nil && level < h.level.Level() {
+		return false
+	}
+	return h.logger.get().Core().Enabled(SlogLevel(level))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	ce := h.logger.get().Check(SlogLevel(record.Level), record.Message)
+	if ce == nil {
+		return nil
+	}
+	if !record.Time.IsZero() {
+		ce.Time = record.Time
+	}
+	if h.addSource && record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		ce.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+	}
+	fields := ContextFields(ctx)
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, attr)
+		return true
+	})
+	ce.Write(fields...)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []zap.Field
+	for _, attr := range attrs {
+		fields = appendAttr(fields, attr)
+	}
+	return h.with(fields...)
+}
+
+// WithGroup 之后的字段都放在 name 中.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(zap.Namespace(name))
+}
+
+func (h *SlogHandler) with(fields ...zap.Field) *SlogHandler {
+	clone := &SlogHandler{module: h.module, addSource: h.addSource, level: h.level}
+	clone.fields = append(append(make([]zap.Field, 0, len(h.fields)+len(fields)), h.fields...), fields...)
+	clone.logger = clone.lazy()
+	return clone
+}
+
+func appendAttr(fields []zap.Field, attr slog.Attr) []zap.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		group := attr.Value.Group()
+		if len(group) == 0 {
+			return fields
+		}
+		if attr.Key == "" {
+			// 空 key 的 group 直接展开
+			for _, item := range group {
+				fields = appendAttr(fields, item)
+			}
+			return fields
+		}
+		return append(fields, zap.Object(attr.Key, slogGroup(group)))
+	}
+	return append(fields, slogField(attr.Key, attr.Value))
+}
+
+func slogField(key string, v slog.Value) zap.Field {
+	switch v.Kind() {
+	case slog.KindString:
+		return zap.String(key, v.String())
+	case slog.KindInt64:
+		return zap.Int64(key, v.Int64())
+	case slog.KindUint64:
+		return zap.Uint64(key, v.Uint64())
+	case slog.KindFloat64:
+		return zap.Float64(key, v.Float64())
+	case slog.KindBool:
+		return zap.Bool(key, v.Bool())
+	case slog.KindDuration:
+		return zap.Duration(key, v.Duration())
+	case slog.KindTime:
+		return zap.Time(key, v.Time())
+	}
+	if err, ok := v.Any().(error); ok {
+		return zap.NamedError(key, err)
+	}
+	return zap.Any(key, v.Any())
+}
+
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range appendAttrs(nil, g) {
+		f.AddTo(enc)
+	}
+	return nil
+}
+
+func appendAttrs(fields []zap.Field, attrs []slog.Attr) []zap.Field {
+	for _, attr := range attrs {
+		fields = appendAttr(fields, attr)
+	}
+	return fields
+}
+
+// GRPCLogger 实现 grpclog.LoggerV2, 把 grpc 内部的日志写入 module.
+type GRPCLogger struct {
+	logger    *lazyLogger
+	verbosity int
+}
+
+var _ grpclog.LoggerV2 = (*GRPCLogger)(nil)
+
+// NewGRPCLogger 创建 module 对应的 grpclog.LoggerV2, verbosity 同 GRPC_GO_LOG_VERBOSITY_LEVEL.
+// logger 在写入时获取, YamlInit 重新初始化后不需要重新创建.
+//
+//	grpclog.SetLoggerV2(logger.NewGRPCLogger("grpc", 0))
+func NewGRPCLogger(module string, verbosity int) *GRPCLogger {
+	return &GRPCLogger{logger: newLazyLogger(module, func(l *zap.Logger) *zap.Logger {
+		// 跳过 GRPCLogger 自身和 grpclog 的封装.
+		return l.WithOptions(zap.AddCallerSkip(2))
+	}), verbosity: verbosity}
+}
+
+func (g *GRPCLogger) sugar() *zap.SugaredLogger {
+	return g.logger.get().Sugar()
+}
+
+func (g *GRPCLogger) Info(args ...interface{})                 { g.sugar().Info(args...) }
+func (g *GRPCLogger) Infoln(args ...interface{})               { g.sugar().Info(sprintln(args)) }
+func (g *GRPCLogger) Infof(format string, args ...interface{}) { g.sugar().Infof(format, args...) }
+func (g *GRPCLogger) Warning(args ...interface{})              { g.sugar().Warn(args...) }
+func (g *GRPCLogger) Warningln(args ...interface{})            { g.sugar().Warn(sprintln(args)) }
+func (g *GRPCLogger) Warningf(format string, args ...interface{}) {
+	g.sugar().Warnf(format, args...)
+}
+func (g *GRPCLogger) Error(args ...interface{})   { g.sugar().Error(args...) }
+func (g *GRPCLogger) Errorln(args ...interface{}) { g.sugar().Error(sprintln(args)) }
+func (g *GRPCLogger) Errorf(format string, args ...interface{}) {
+	g.sugar().Errorf(format, args...)
+}
+
+// Fatal 等同于 grpclog 的默认实现: 记录后退出进程.
+func (g *GRPCLogger) Fatal(args ...interface{})   { g.sugar().Fatal(args...) }
+func (g *GRPCLogger) Fatalln(args ...interface{}) { g.sugar().Fatal(sprintln(args)) }
+func (g *GRPCLogger) Fatalf(format string, args ...interface{}) {
+	g.sugar().Fatalf(format, args...)
+}
+
+// V 日志等级是否小于等于 verbosity.
+func (g *GRPCLogger) V(l int) bool {
+	return l <= g.verbosity
+}
+
+func sprintln(args []interface{}) string {
+	s := fmt.Sprintln(args...)
+	return s[:len(s)-1]
+}
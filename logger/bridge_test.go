@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRedirectStdLog(t *testing.T) {
+	YamlInit([]byte(`logging:
+  stdlog:
+    handler:
+      - typ: memory
+        level: debug`))
+	restore, err := RedirectStdLog("stdlog", zapcore.WarnLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Printf("from %s", "std")
+	restore()
+	res := Memory("stdlog").ByMessage("from std")
+	if len(res) != 1 || res[0].Level != zapcore.WarnLevel {
+		t.Errorf("got %+v", res)
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	YamlInit([]byte(`logging:
+  slog:
+    handler:
+      - typ: memory
+        level: info`))
+	l := slog.New(NewSlogHandler("slog", &slog.HandlerOptions{AddSource: true}))
+	l.Debug("skip")
+	ctx := WithRequestID(context.Background(), "req-1")
+	l.With("service", "api").WithGroup("req").InfoContext(ctx, "handled",
+		"status", 200,
+		slog.Group("user", "id", 7),
+		"err", errors.New("boom"),
+	)
+	m := Memory("slog")
+	if len(m.Entries()) != 1 {
+		t.Fatalf("entries=%+v", m.Entries())
+	}
+	ent := m.Entries()[0]
+	req, _ := ent.Fields["req"].(map[string]interface{})
+	user, _ := req["user"].(map[string]interface{})
+	if ent.Fields["service"] != "api" || req["status"] == nil || user["id"] == nil || req["err"] != "boom" || req["request_id"] != "req-1" {
+		t.Errorf("fields=%+v", ent.Fields)
+	}
+	if ent.Caller == "" {
+		t.Error("caller not set")
+	}
+	if SlogLevel(slog.LevelWarn+1) != zapcore.WarnLevel || SlogLevel(slog.LevelDebug-4) != zapcore.DebugLevel {
+		t.Error("unexpected level mapping")
+	}
+}
+
+func TestSlogHandlerModuleOptions(t *testing.T) {
+	YamlInit([]byte(`logging:
+  slog_opts:
+    handler:
+      - typ: memory
+        level: info
+    caller: true
+    stacktrace_level: error
+    str_field:
+      - key: service
+        value: api`))
+	l := slog.New(NewSlogHandler("slog_opts", nil))
+	l.Info("info")
+	l.Error("failed")
+	m := Memory("slog_opts")
+	if len(m.Entries()) != 2 {
+		t.Fatalf("entries=%+v", m.Entries())
+	}
+	info, failed := m.Entries()[0], m.Entries()[1]
+	if info.Fields["service"] != "api" || info.Stack != "" || failed.Stack == "" {
+		t.Errorf("info=%+v, failed=%+v", info, failed)
+	}
+	// 调用位置只由 AddSource 控制, 不会输出 bridge.go.
+	if info.Caller != "" {
+		t.Errorf("caller=%s", info.Caller)
+	}
+}
+
+func TestGRPCLogger(t *testing.T) {
+	YamlInit([]byte(`logging:
+  grpclog:
+    handler:
+      - typ: memory
+        level: debug`))
+	g := NewGRPCLogger("grpclog", 1)
+	g.Infoln("a", "b")
+	g.Warningf("retry %d", 3)
+	g.Error("failed")
+	m := Memory("grpclog")
+	if len(m.ByMessage("a b")) != 1 || len(m.ByMessage("retry 3")) != 1 || len(m.ByLevel(zapcore.ErrorLevel)) != 1 {
+		t.Errorf("entries=%+v", m.Entries())
+	}
+	if !g.V(1) || g.V(2) {
+		t.Error("unexpected verbosity")
+	}
+}
+
+func TestBridgeReinit(t *testing.T) {
+	data := []byte(`logging:
+  bridge_reinit:
+    handler:
+      - typ: memory
+        level: debug`)
+	YamlInit(data)
+	sl := slog.New(NewSlogHandler("bridge_reinit", nil)).With("k", "v")
+	gl := NewGRPCLogger("bridge_reinit", 0)
+	sl.Info("before")
+
+	// 重新初始化后写入新的 handler, 不需要重新创建.
+	YamlInit(data)
+	sl.Info("slog")
+	gl.Info("grpc")
+	res := Memory("bridge_reinit").Entries()
+	if len(res) != 2 || res[0].Message != "slog" || res[0].Fields["k"] != "v" || res[1].Message != "grpc" {
+		t.Errorf("entries=%+v", res)
+	}
+}
@@ -0,0 +1,400 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 10:20
+// @File   : buffer.go
+// @Project: utils/logger
+// ==========================
+
+// 缓冲区满时的处理策略.
+const (
+	OverflowBlock      = "block"       // 阻塞写入方, 直到缓冲区被刷出.
+	OverflowDropNewest = "drop_newest" // 丢弃新写入的日志.
+	OverflowDropLow    = "drop_low"    // 优先丢弃缓冲区中的 debug/info 日志, 仍然放不下时丢弃新的 debug/info, 更高等级则阻塞.
+)
+
+// BufferConfig yaml 中 handler.buffer 的配置.
+type BufferConfig struct {
+	Size          int    `yaml:"size"`           // 环形缓冲区可容纳的日志条数, 默认 1024
+	FlushInterval string `yaml:"flush_interval"` // 定时刷出的间隔, 默认 1s
+	Overflow      string `yaml:"overflow"`       // block | drop_newest | drop_low, 默认 block
+}
+
+// BufferOption 由 BufferConfig 解析得到.
+type BufferOption struct {
+	Size          int
+	FlushInterval time.Duration
+	Overflow      string
+	BatchSize     int  // 每次写入 out 的最多条数, 缓冲区中达到该条数时通知后台协程刷出, 0 表示不限(http 使用)
+	AsyncError    bool // error 及以上等级只通知后台协程立即刷出, 不在写入方同步写 out(out 较慢时, 如 http)
+}
+
+func (c *BufferConfig) Option() (*BufferOption, error) {
+	opt := &BufferOption{Size: c.Size, Overflow: c.Overflow}
+	if opt.Size <= 0 {
+		opt.Size = 1024
+	}
+	if c.FlushInterval == "" {
+		opt.FlushInterval = time.Second
+	} else {
+		du, err := time.ParseDuration(c.FlushInterval)
+		if err != nil || du <= 0 {
+			return nil, errors.New("invalid buffer flush_interval: " + c.FlushInterval)
+		}
+		opt.FlushInterval = du
+	}
+	switch opt.Overflow {
+	case "":
+		opt.Overflow = OverflowBlock
+	case OverflowBlock, OverflowDropNewest, OverflowDropLow:
+	default:
+		return nil, errors.New("invalid buffer overflow: " + c.Overflow)
+	}
+	return opt, nil
+}
+
+// LevelWriter 能感知日志等级的 writer. Build 时如果 handler 的 writer 实现了该接口,
+// 会使用 levelCore 代替 zapcore.NewCore, 以便把等级传递给 writer.
+type LevelWriter interface {
+	io.Writer
+	WriteLevel(level zapcore.Level, p []byte) (int, error)
+}
+
+type bufferEntry struct {
+	level zapcore.Level
+	data  []byte
+}
+
+// BufferedWriter 异步写入的 writer, 日志先写入环形缓冲区, 由后台协程定时刷到 out.
+// error 及以上等级的日志会立即(同步)刷出, AsyncError 时由后台协程立即刷出.
+type BufferedWriter struct {
+	out io.Writer
+	opt BufferOption
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	items   []bufferEntry // 环形缓冲区
+	head    int           // 最早一条日志的位置
+	size    int           // 实际元素个数
+	closed  bool
+
+	flushMu sync.Mutex // 保证刷出顺序
+	kick    chan struct{}
+	done    chan struct{}
+
+	dropped [zapcore.FatalLevel - zapcore.DebugLevel + 1]uint64 // 按等级统计的丢弃条数
+}
+
+func NewBufferedWriter(out io.Writer, opt BufferOption) *BufferedWriter {
+	w := &BufferedWriter{
+		out:   out,
+		opt:   opt,
+		items: make([]bufferEntry, opt.Size),
+		kick:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+	w.notFull = sync.NewCond(&w.mu)
+	go w.loop()
+	return w
+}
+
+func (w *BufferedWriter) loop() {
+	ticker := time.NewTicker(w.opt.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-w.kick:
+		case <-w.done:
+			return
+		}
+		w.Flush()
+	}
+}
+
+func (w *BufferedWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zapcore.InfoLevel, p)
+}
+
+// WriteLevel 写入缓冲区. 返回值与 io.Writer 一致, 被丢弃的日志同样返回 len(p), 不视为错误.
+func (w *BufferedWriter) WriteLevel(level zapcore.Level, p []byte) (int, error) {
+	// p 来自 zap 的 buffer pool, 写入后会被复用, 因此需要拷贝.
+	data := make([]byte, len(p))
+	copy(data, p)
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return w.out.Write(p)
+	}
+	for w.size == len(w.items) {
+		w.signal()
+		if w.overflow(level) {
+			w.mu.Unlock()
+			w.drop(level)
+			return len(p), nil
+		}
+		if w.size < len(w.items) {
+			break
+		}
+		w.notFull.Wait()
+		if w.closed {
+			w.mu.Unlock()
+			return w.out.Write(p)
+		}
+	}
+	w.items[(w.head+w.size)%len(w.items)] = bufferEntry{level: level, data: data}
+	w.size++
+	if w.opt.BatchSize > 0 && w.size >= w.opt.BatchSize {
+		// 已满一批, 交给后台协程发送.
+		w.signal()
+	}
+	w.mu.Unlock()
+
+	if level >= zapcore.ErrorLevel {
+		if w.opt.AsyncError {
+			w.signal()
+			return len(p), nil
+		}
+		if err := w.Flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// overflow 缓冲区已满时调用(持有 w.mu). 返回 true 表示应丢弃新日志;
+// 返回 false 时, 如果腾出了位置则可以直接写入, 否则需要等待.
+func (w *BufferedWriter) overflow(level zapcore.Level) bool {
+	switch w.opt.Overflow {
+	case OverflowDropNewest:
+		return true
+	case OverflowDropLow:
+		if w.evictLow() {
+			return false
+		}
+		return level <= zapcore.InfoLevel
+	default:
+		return false
+	}
+}
+
+// evictLow 移除缓冲区中最早的一条 debug/info 日志.
+func (w *BufferedWriter) evictLow() bool {
+	n := len(w.items)
+	for i := 0; i < w.size; i++ {
+		idx := (w.head + i) % n
+		lvl := w.items[idx].level
+		if lvl > zapcore.InfoLevel {
+			continue
+		}
+		// 后面的元素依次前移.
+		for j := i; j+1 < w.size; j++ {
+			w.items[(w.head+j)%n] = w.items[(w.head+j+1)%n]
+		}
+		w.size--
+		w.items[(w.head+w.size)%n] = bufferEntry{}
+		w.drop(lvl)
+		return true
+	}
+	return false
+}
+
+func (w *BufferedWriter) drop(level zapcore.Level) {
+	if level < zapcore.DebugLevel || level > zapcore.FatalLevel {
+		level = zapcore.FatalLevel
+	}
+	atomic.AddUint64(&w.dropped[level-zapcore.DebugLevel], 1)
+}
+
+func (w *BufferedWriter) signal() {
+	select {
+	case w.kick <- struct{}{}:
+	default:
+	}
+}
+
+// Flush 把缓冲区中的日志写入 out, 设置了 BatchSize 时每次最多写入 BatchSize 条, 写入失败时丢弃剩余的批次.
+func (w *BufferedWriter) Flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	if w.size == 0 {
+		w.mu.Unlock()
+		return nil
+	}
+	per := w.size
+	if w.opt.BatchSize > 0 && w.opt.BatchSize < per {
+		per = w.opt.BatchSize
+	}
+	batches := make([][]byte, 0, (w.size+per-1)/per)
+	n := len(w.items)
+	for start := 0; start < w.size; start += per {
+		end := start + per
+		if end > w.size {
+			end = w.size
+		}
+		total := 0
+		for i := start; i < end; i++ {
+			total += len(w.items[(w.head+i)%n].data)
+		}
+		batch := make([]byte, 0, total)
+		for i := start; i < end; i++ {
+			idx := (w.head + i) % n
+			batch = append(batch, w.items[idx].data...)
+			w.items[idx] = bufferEntry{}
+		}
+		batches = append(batches, batch)
+	}
+	w.head = 0
+	w.size = 0
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+
+	for _, batch := range batches {
+		if _, err := w.out.Write(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *BufferedWriter) Sync() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if s, ok := w.out.(zapcore.WriteSyncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Close 停止后台协程并刷出剩余日志, 之后的写入直接写到 out.
+func (w *BufferedWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.done)
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+	return w.Flush()
+}
+
+// Dropped 返回被丢弃的日志总条数.
+func (w *BufferedWriter) Dropped() uint64 {
+	var total uint64
+	for i := range w.dropped {
+		total += atomic.LoadUint64(&w.dropped[i])
+	}
+	return total
+}
+
+// DroppedByLevel 返回各等级被丢弃的日志条数.
+func (w *BufferedWriter) DroppedByLevel() map[zapcore.Level]uint64 {
+	res := make(map[zapcore.Level]uint64)
+	for i := range w.dropped {
+		if count := atomic.LoadUint64(&w.dropped[i]); count > 0 {
+			res[zapcore.DebugLevel+zapcore.Level(i)] = count
+		}
+	}
+	return res
+}
+
+var (
+	bufferedMu      sync.Mutex
+	bufferedWriters = map[string]*BufferedWriter{}
+)
+
+// registerBuffered 记录 handler 创建的 BufferedWriter, 用于 DroppedEntries 统计.
+func registerBuffered(filename string, w *BufferedWriter) *BufferedWriter {
+	bufferedMu.Lock()
+	defer bufferedMu.Unlock()
+	bufferedWriters[filename] = w
+	return w
+}
+
+// unregisterBuffered 在 writer 关闭时调用, 文件已被新的 writer 注册时不删除.
+func unregisterBuffered(w *BufferedWriter) {
+	bufferedMu.Lock()
+	defer bufferedMu.Unlock()
+	for filename, item := range bufferedWriters {
+		if item == w {
+			delete(bufferedWriters, filename)
+		}
+	}
+}
+
+// DroppedEntries 返回各个开启了 buffer 的文件被丢弃的日志条数, key 为文件名.
+func DroppedEntries() map[string]uint64 {
+	bufferedMu.Lock()
+	defer bufferedMu.Unlock()
+	res := make(map[string]uint64, len(bufferedWriters))
+	for filename, w := range bufferedWriters {
+		res[filename] = w.Dropped()
+	}
+	return res
+}
+
+// levelCore 与 zapcore.NewCore 创建的 core 相同, 只是写入时会把日志等级传递给 LevelWriter.
+type levelCore struct {
+	zapcore.LevelEnabler
+	enc zapcore.Encoder
+	out LevelWriter
+}
+
+func newLevelCore(enc zapcore.Encoder, out LevelWriter, enab zapcore.LevelEnabler) zapcore.Core {
+	return &levelCore{LevelEnabler: enab, enc: enc, out: out}
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &levelCore{LevelEnabler: c.LevelEnabler, enc: c.enc.Clone(), out: c.out}
+	for i := range fields {
+		fields[i].AddTo(clone.enc)
+	}
+	return clone
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *levelCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	_, err = c.out.WriteLevel(ent.Level, buf.Bytes())
+	buf.Free()
+	if err != nil {
+		return err
+	}
+	if ent.Level > zapcore.ErrorLevel {
+		_ = c.Sync()
+	}
+	return nil
+}
+
+func (c *levelCore) Sync() error {
+	if s, ok := c.out.(zapcore.WriteSyncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// newManualBufferedWriter 与 NewBufferedWriter 相同, 但不启动后台刷出协程, 便于断言缓冲区状态.
+func newManualBufferedWriter(out io.Writer, opt BufferOption) *BufferedWriter {
+	w := &BufferedWriter{
+		out:   out,
+		opt:   opt,
+		items: make([]bufferEntry, opt.Size),
+		kick:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+	w.notFull = sync.NewCond(&w.mu)
+	return w
+}
+
+func TestBufferedWriter_Flush(t *testing.T) {
+	out := &lockedBuffer{}
+	w := NewBufferedWriter(out, BufferOption{Size: 8, FlushInterval: time.Hour, Overflow: OverflowBlock})
+	defer w.Close()
+	w.WriteLevel(zapcore.InfoLevel, []byte("a\n"))
+	w.WriteLevel(zapcore.DebugLevel, []byte("b\n"))
+	if out.String() != "" {
+		t.Errorf("info should be buffered, got %q", out.String())
+	}
+	// error 立即刷出, 且保持顺序.
+	w.WriteLevel(zapcore.ErrorLevel, []byte("c\n"))
+	if out.String() != "a\nb\nc\n" {
+		t.Errorf("error should flush, got %q", out.String())
+	}
+}
+
+func TestBufferedWriter_Overflow(t *testing.T) {
+	out := &lockedBuffer{}
+	w := newManualBufferedWriter(out, BufferOption{Size: 2, FlushInterval: time.Hour, Overflow: OverflowDropNewest})
+	for i := 0; i < 5; i++ {
+		w.WriteLevel(zapcore.InfoLevel, []byte(strconv.Itoa(i)))
+	}
+	if w.Dropped() != 3 {
+		t.Errorf("drop_newest dropped=%v, want 3", w.Dropped())
+	}
+	if len(w.kick) != 1 {
+		t.Error("drop_newest should request an early flush when full")
+	}
+	w.Close()
+	if out.String() != "01" {
+		t.Errorf("drop_newest got %q, want %q", out.String(), "01")
+	}
+
+	out = &lockedBuffer{}
+	w = newManualBufferedWriter(out, BufferOption{Size: 2, FlushInterval: time.Hour, Overflow: OverflowDropLow})
+	w.WriteLevel(zapcore.InfoLevel, []byte("i"))
+	w.WriteLevel(zapcore.WarnLevel, []byte("w"))
+	w.WriteLevel(zapcore.WarnLevel, []byte("W"))  // 挤掉 i
+	w.WriteLevel(zapcore.DebugLevel, []byte("d")) // 没有可挤掉的低等级日志, 丢弃自身
+	if got := w.DroppedByLevel(); got[zapcore.InfoLevel] != 1 || got[zapcore.DebugLevel] != 1 {
+		t.Errorf("drop_low dropped=%v", got)
+	}
+	if len(w.kick) != 1 {
+		t.Error("drop_low should request an early flush when full")
+	}
+	w.Close()
+	if out.String() != "wW" {
+		t.Errorf("drop_low got %q, want %q", out.String(), "wW")
+	}
+}
+
+func TestBufferedWriter_Block(t *testing.T) {
+	out := &lockedBuffer{}
+	w := NewBufferedWriter(out, BufferOption{Size: 2, FlushInterval: time.Hour, Overflow: OverflowBlock})
+	defer w.Close()
+	for i := 0; i < 10; i++ {
+		w.WriteLevel(zapcore.InfoLevel, []byte(strconv.Itoa(i)))
+	}
+	w.Flush()
+	if out.String() != "0123456789" || w.Dropped() != 0 {
+		t.Errorf("block got %q, dropped=%v", out.String(), w.Dropped())
+	}
+}
+
+func TestBufferedWriter_FlushWhenFull(t *testing.T) {
+	for _, overflow := range []string{OverflowDropNewest, OverflowDropLow} {
+		out := &lockedBuffer{}
+		w := NewBufferedWriter(out, BufferOption{Size: 2, FlushInterval: time.Hour, Overflow: overflow})
+		w.WriteLevel(zapcore.WarnLevel, []byte("a"))
+		w.WriteLevel(zapcore.WarnLevel, []byte("b"))
+		if out.String() != "" {
+			t.Errorf("%s: should be buffered, got %q", overflow, out.String())
+		}
+		// 缓冲区已满, 不等 flush_interval 即刷出.
+		w.WriteLevel(zapcore.WarnLevel, []byte("c"))
+		deadline := time.Now().Add(time.Second)
+		for out.String() == "" && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if got := out.String(); got == "" || got[:2] != "ab" {
+			t.Errorf("%s: full buffer should flush early, got %q", overflow, got)
+		}
+		w.Close()
+	}
+}
@@ -1,12 +1,17 @@
 package logger
 
 import (
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/yaml.v2"
+	"io"
 	"net"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
@@ -17,14 +22,31 @@ import (
 // @Project: aotu/logger
 // ==========================
 
+// YamlInit 解析配置并初始化各 module 的 logger, 环境变量的替换和覆盖见 ParseConfig, EnvPrefix.
 func YamlInit(data []byte) {
-	temp := Config{}
-	if err := yaml.Unmarshal(data, &temp); err != nil {
+	temp, err := ParseConfig(data)
+	if err != nil {
 		panic(err)
 	}
-	temp.Transform()
+	logging := temp.transform()
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	// 重复初始化时关闭上一次创建的 handler 并丢弃缓存的 logger, 否则旧的切割协程, 文件等会继续存在,
+	// 同一进程内两个 RotateFile 切割同一个文件; 新配置中不存在的 module 同样删除.
+	for key, writers := range loggerWriters {
+		for _, w := range writers {
+			if err := closeWriter(w); err != nil {
+				fmt.Println(time.Now().Format(time.RFC3339), syscall.Getpid(), "[close handler]", key, err)
+			}
+		}
+	}
+	loggerMap = map[string]*zap.Logger{}
+	loggerSugarMap = map[string]*zap.SugaredLogger{}
+	loggerWriters = map[string][]io.Writer{}
+	Logging = logging
+	atomic.AddUint64(&loggerGen, 1)
 	for key := range Logging {
-		SugarL(key)
+		loggerSugarMap[key] = buildLogger(key).Sugar()
 	}
 }
 
@@ -88,18 +110,120 @@ func (f *StringField) GetValue() string {
 	}
 }
 
+type HandlerConfig struct {
+	Typ       string           `yaml:"typ"`
+	Name      string           `yaml:"name"` // 可选, 用于环境变量覆盖, 见 EnvPrefix
+	Filename  string           `yaml:"filename"`
+	Level     string           `yaml:"level"`
+	Format    string           `yaml:"format"`
+	Duration  string           `yaml:"duration"`
+	Replica   int              `yaml:"replica"`
+	Retention *RetentionConfig `yaml:"retention"` // rotate_file 历史文件的保留策略, 默认只按 replica
+	StrField  []StringField    `yaml:"str_field"`
+	Buffer    *BufferConfig    `yaml:"buffer"` // 仅 file, rotate_file, tcp, udp 支持
+
+	Reopen      bool   `yaml:"reopen"`       // 仅 file, rotate_file 支持
+	ReopenCheck string `yaml:"reopen_check"` // 检测外部切割的间隔, 默认 10s, "0" 表示不检测
+	Shared      bool   `yaml:"shared"`       // 仅 rotate_file 支持, 多进程共享同一个文件
+
+	// rotate_file 切割完成后执行的 hook, 见 RegisterRotateHookType
+	Hooks       []yaml.MapSlice `yaml:"hooks"`
+	HookRetry   int             `yaml:"hook_retry"`   // 失败后的重试次数, 默认 3
+	HookBackoff string          `yaml:"hook_backoff"` // 第一次重试的间隔, 之后每次翻倍, 默认 1s
+
+	// syslog, tcp, udp, http
+	Network       string            `yaml:"network"`        // syslog: unix | unixgram | udp | tcp
+	Address       string            `yaml:"address"`        // syslog, tcp, udp 的地址
+	Facility      string            `yaml:"facility"`       // syslog facility, 默认 user
+	AppName       string            `yaml:"app_name"`       // syslog APP-NAME
+	URL           string            `yaml:"url"`            // http
+	Headers       map[string]string `yaml:"headers"`        // http 请求头
+	Style         string            `yaml:"style"`          // http: ndjson | es_bulk, 默认 ndjson
+	BatchSize     int               `yaml:"batch_size"`     // http 每批最多发送的条数, 默认 100
+	FlushInterval string            `yaml:"flush_interval"` // http 发送间隔, 默认 1s
+	Timeout       string            `yaml:"timeout"`        // 连接/请求超时, 默认 5s
+
+	Encoder     *EncoderConfig  `yaml:"encoder"`      // 自定义 key, 时间格式等
+	Sampling    *SamplingConfig `yaml:"sampling"`     // 所有类型通用
+	Filter      *FilterConfig   `yaml:"filter"`       // 所有类型通用
+	MinInterval string          `yaml:"min_interval"` // email: 相同内容的日志在该时间内只发送一次
+	Size        int             `yaml:"size"`         // memory: 保留的条数, 默认 1000
+
+	raw yaml.MapSlice
+}
+
+// UnmarshalYAML 同时保留原始的 yaml 节点, 交给 RegisterHandlerType 注册的 factory 解析.
+// 自定义类型的字段可能与内置字段类型不同, 因此这里忽略内置字段的解析错误, 由内置 factory 再次解析时报告.
+func (h *HandlerConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw yaml.MapSlice
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	type plain HandlerConfig
+	_ = unmarshal((*plain)(h))
+	h.raw = raw
+	for _, item := range raw {
+		if item.Key == "typ" {
+			h.Typ, _ = item.Value.(string)
+		}
+	}
+	return nil
+}
+
+// node 返回 handler 的原始 yaml 节点, 直接在代码中构造的 HandlerConfig 由字段生成.
+func (h *HandlerConfig) node() (yaml.MapSlice, error) {
+	if h.raw != nil {
+		return h.raw, nil
+	}
+	data, err := yaml.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	var raw yaml.MapSlice
+	err = yaml.Unmarshal(data, &raw)
+	return raw, err
+}
+
+// BufferOption 解析 buffer 配置, 未配置时返回 nil.
+func (h *HandlerConfig) BufferOption() (*BufferOption, error) {
+	if h.Buffer == nil {
+		return nil, nil
+	}
+	return h.Buffer.Option()
+}
+
+// ParseDuration 解析可选的时间配置, 为空时返回 0.
+func (h *HandlerConfig) ParseDuration(name, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	du, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.New("invalid " + name + ":" + value)
+	}
+	return du, nil
+}
+
+// ReopenCheckDuration 解析 reopen_check 配置.
+func (h *HandlerConfig) ReopenCheckDuration() (time.Duration, error) {
+	if !h.Reopen {
+		return 0, nil
+	}
+	if h.ReopenCheck == "" {
+		return 10 * time.Second, nil
+	}
+	return h.ParseDuration("reopen_check", h.ReopenCheck)
+}
+
 type ConfigHandler struct {
-	Handler []struct {
-		Typ      string        `yaml:"typ"`
-		Filename string        `yaml:"filename"`
-		Level    string        `yaml:"level"`
-		Format   string        `yaml:"format"`
-		Duration string        `yaml:"duration"`
-		Replica  int           `yaml:"replica"`
-		StrField []StringField `yaml:"str_field"`
-	} `yaml:"handler"`
-	Caller   bool          `yaml:"caller"`
-	StrField []StringField `yaml:"str_field"`
+	Handler  []HandlerConfig `yaml:"handler"`
+	Caller   bool            `yaml:"caller"`
+	StrField []StringField   `yaml:"str_field"`
+	Redact   []RedactRule    `yaml:"redact"` // 脱敏规则, 对该 module 的所有 handler 生效
+
+	StacktraceLevel string `yaml:"stacktrace_level"` // 该等级及以上的日志附带调用栈, 为空不附带
+	CallerSkip      int    `yaml:"caller_skip"`      // 跳过的调用层数, 用于封装了 logger 的函数
+	ErrorChain      bool   `yaml:"error_chain"`      // 是否把 error 字段展开为 cause 链, 见 ErrorChain
 }
 
 type Config struct {
@@ -107,12 +231,29 @@ type Config struct {
 }
 
 func (c *Config) Transform() {
+	logging := c.transform()
+	loggerMu.Lock()
+	Logging = logging
+	loggerMu.Unlock()
+}
+
+func (c *Config) transform() map[string]LoggingConfig {
 	tempConfig := map[string]LoggingConfig{}
 	for key, handlers := range c.Logging {
 		opts := make([]zap.Option, 0)
 		if handlers.Caller {
 			opts = append(opts, zap.AddCaller())
 		}
+		if handlers.CallerSkip != 0 {
+			opts = append(opts, zap.AddCallerSkip(handlers.CallerSkip))
+		}
+		if handlers.StacktraceLevel != "" {
+			level, ok := LogLevelMap[handlers.StacktraceLevel]
+			if !ok {
+				panic(fmt.Sprintf("module %s: invalid stacktrace_level: %s", key, handlers.StacktraceLevel))
+			}
+			opts = append(opts, zap.AddStacktrace(level))
+		}
 		fields := make([]zap.Field, 0)
 		if len(handlers.StrField) > 0 {
 			for _, item := range handlers.StrField {
@@ -128,85 +269,83 @@ func (c *Config) Transform() {
 		}
 		opts = append(opts, zap.Fields(fields...))
 		tempHandlers := make([]Handler, 0)
-		for _, handler := range handlers.Handler {
-			format := ConsoleFormatter
-			if handler.Format == "json" {
-				format = JsonFormatter
+		for i := range handlers.Handler {
+			temp, err := buildHandler(key, i, &handlers.Handler[i])
+			if err != nil {
+				panic(err.Error())
 			}
-			level, ok := LogLevelMap[handler.Level]
-			if !ok {
-				panic("level invalid: " + handler.Level)
+			tempHandlers = append(tempHandlers, temp)
+		}
+		wraps := make([]func(zapcore.Core) zapcore.Core, 0)
+		if len(handlers.Redact) > 0 {
+			redactor, err := NewRedactor(handlers.Redact)
+			if err != nil {
+				panic(fmt.Sprintf("module %s: %v", key, err))
 			}
-			var temp Handler
-			switch handler.Typ {
-			case "file":
-				temp = &FileHandler{
-					Filename: handler.Filename,
-					Level:    level,
-					Format:   format,
-				}
-			case "rotate_file":
-				du, err := time.ParseDuration(handler.Duration)
-				if err != nil {
-					panic("invalid duration:" + handler.Duration)
-				}
-				var layout string
-				if du >= time.Hour*24 {
-					layout = "2006-01-02"
-				} else if du >= time.Hour {
-					layout = "2006-01-02_15"
-				} else if du >= time.Minute {
-					layout = "2006-01-02_15_04"
-				} else {
-					layout = "2006-01-02_15_04_05"
-				}
-				temp = &RotateHandler{
-					Filename: handler.Filename,
-					Layout:   layout,
-					Duration: du,
-					Replica:  handler.Replica,
-					Format:   format,
-					Level:    level,
-				}
-			case "email":
-				subjectL := make([]string, 0)
-				for _, item := range handler.StrField {
-					value := item.GetValue()
-					if value != "" {
-						subjectL = append(subjectL, item.Key+"="+item.GetValue())
-					}
-				}
-				temp = &EmailHandler{
-					Level:   level,
-					Format:  format,
-					Subject: strings.Join(subjectL, ";"),
-				}
+			wraps = append(wraps, redactor.WrapCore)
+		}
+		if handlers.ErrorChain {
+			// 在脱敏之前展开, 展开后的内容同样会被脱敏.
+			wraps = append(wraps, errorChainWrap)
+		}
+		names := make([]string, 0, len(handlers.Handler))
+		for i, item := range handlers.Handler {
+			name := item.Name
+			if name == "" {
+				name = fmt.Sprintf("%s.%d", item.Typ, i)
 			}
-			tempHandlers = append(tempHandlers, temp)
+			names = append(names, name)
 		}
-		tempConfig[key] = LoggingConfig{Handlers: tempHandlers, Opts: opts}
+		tempConfig[key] = LoggingConfig{Module: key, Handlers: tempHandlers, Names: names, Opts: opts, Wraps: wraps}
 	}
-	Logging = tempConfig
+	return tempConfig
 }
 
 type LoggingConfig struct {
+	Module   string // module 名称, 为空时由 L 设置
 	Handlers []Handler
+	Names    []string // 各 handler 的名称, 用于统计, 未设置时为下标
 	Opts     []zap.Option
+	Wraps    []func(zapcore.Core) zapcore.Core // 包装每个 handler 的 core(在 handler 自身的 CoreWrapper 之前), 如脱敏
 }
 
 func (cfg *LoggingConfig) Build() *zap.Logger {
+	logger, _ := cfg.build()
+	return logger
+}
+
+// build 同 Build, 同时返回各 handler 创建的 writer, 用于重新初始化时关闭.
+func (cfg *LoggingConfig) build() (*zap.Logger, []io.Writer) {
 	var Cores []zapcore.Core
-	for _, handler := range cfg.Handlers {
+	writers := make([]io.Writer, 0, len(cfg.Handlers))
+	for i, handler := range cfg.Handlers {
 		writer, err := handler.BuildWriter()
 		if err != nil {
 			panic(fmt.Sprintf("%v, %v", err, handler))
 		}
-		syncer := zapcore.AddSync(writer)
-		tempCore := zapcore.NewCore(handler.GetFormat(), syncer, handler.GetLevel())
+		writers = append(writers, writer)
+		name := strconv.Itoa(i)
+		if i < len(cfg.Names) {
+			name = cfg.Names[i]
+		}
+		writer = meterWriter(cfg.Module, name, writer)
+		var tempCore zapcore.Core
+		if lw, ok := writer.(LevelWriter); ok {
+			tempCore = newLevelCore(handler.GetFormat(), lw, handler.GetLevel())
+		} else {
+			syncer := zapcore.AddSync(writer)
+			tempCore = zapcore.NewCore(handler.GetFormat(), syncer, handler.GetLevel())
+		}
+		for _, wrap := range cfg.Wraps {
+			tempCore = wrap(tempCore)
+		}
+		if w, ok := handler.(CoreWrapper); ok {
+			tempCore = w.WrapCore(tempCore)
+		}
 		Cores = append(Cores, tempCore)
 	}
 	core := zapcore.NewTee(Cores...)
-	return zap.New(core, cfg.Opts...)
+	return zap.New(core, cfg.Opts...), writers
 }
 
 //
@@ -0,0 +1,204 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 17:40
+// @File   : context.go
+// @Project: utils/logger
+// ==========================
+
+// 从 context 中提取的字段名称
+const (
+	FieldTraceID   = "trace_id"
+	FieldSpanID    = "span_id"
+	FieldRequestID = "request_id"
+	FieldUserID    = "user_id"
+)
+
+// ContextExtractor 从 context 中提取日志字段.
+type ContextExtractor func(ctx context.Context) []zap.Field
+
+type namedExtractor struct {
+	name string
+	f    ContextExtractor
+}
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []namedExtractor
+)
+
+// RegisterContextExtractor 注册 context 字段提取器, 重复注册会 panic.
+// 提取器按注册顺序执行, 同名字段以先提取到的为准. 内置的 context(WithTraceID 等) 和 grpc(incoming metadata) 提取器最先注册.
+func RegisterContextExtractor(name string, f ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	for _, item := range extractors {
+		if item.name == name {
+			panic("context extractor " + name + " 重复注册")
+		}
+	}
+	extractors = append(extractors, namedExtractor{name: name, f: f})
+}
+
+// ContextFields 返回所有提取器从 ctx 中提取到的字段.
+func ContextFields(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+	var res []zap.Field
+	seen := map[string]bool{}
+	for _, item := range extractors {
+		for _, f := range item.f(ctx) {
+			if seen[f.Key] {
+				continue
+			}
+			seen[f.Key] = true
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// Ctx 返回附带了 ctx 中 trace_id, request_id 等字段的 logger.
+func Ctx(ctx context.Context, module string) *zap.Logger {
+	fields := ContextFields(ctx)
+	if len(fields) == 0 {
+		return L(module)
+	}
+	return L(module).With(fields...)
+}
+
+// SugarCtx 同 Ctx, 返回 SugaredLogger.
+func SugarCtx(ctx context.Context, module string) *zap.SugaredLogger {
+	return Ctx(ctx, module).Sugar()
+}
+
+type ctxKey string
+
+func withValue(ctx context.Context, key, value string) context.Context {
+	return context.WithValue(ctx, ctxKey(key), value)
+}
+
+func ctxValue(ctx context.Context, key string) string {
+	if ctx == nil {
+		return ""
+	}
+	v, _ := ctx.Value(ctxKey(key)).(string)
+	return v
+}
+
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return withValue(ctx, FieldTraceID, id)
+}
+
+func WithSpanID(ctx context.Context, id string) context.Context {
+	return withValue(ctx, FieldSpanID, id)
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return withValue(ctx, FieldRequestID, id)
+}
+
+func WithUserID(ctx context.Context, id string) context.Context {
+	return withValue(ctx, FieldUserID, id)
+}
+
+// TraceID 返回 ctx 中的 trace id, 依次查找 WithTraceID 的值和 grpc incoming metadata.
+func TraceID(ctx context.Context) string {
+	return lookup(ctx, FieldTraceID)
+}
+
+// SpanID 同 TraceID.
+func SpanID(ctx context.Context) string {
+	return lookup(ctx, FieldSpanID)
+}
+
+// RequestID 同 TraceID.
+func RequestID(ctx context.Context) string {
+	return lookup(ctx, FieldRequestID)
+}
+
+// UserID 同 TraceID.
+func UserID(ctx context.Context) string {
+	return lookup(ctx, FieldUserID)
+}
+
+func lookup(ctx context.Context, field string) string {
+	if v := ctxValue(ctx, field); v != "" {
+		return v
+	}
+	return grpcMetadata(ctx)[field]
+}
+
+// GRPCMetadataKeys grpc metadata 的 key(小写) 与日志字段的对应关系. traceparent(W3C) 会被解析为 trace_id 和 span_id.
+var GRPCMetadataKeys = map[string]string{
+	"x-trace-id":   FieldTraceID,
+	"x-span-id":    FieldSpanID,
+	"x-request-id": FieldRequestID,
+	"x-user-id":    FieldUserID,
+}
+
+// grpcMetadata 从 incoming metadata 中读取字段.
+func grpcMetadata(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+	res := map[string]string{}
+	if v := md.Get("traceparent"); len(v) > 0 {
+		// version-traceid-spanid-flags
+		parts := strings.Split(v[0], "-")
+		if len(parts) == 4 && len(parts[1]) == 32 && len(parts[2]) == 16 {
+			res[FieldTraceID] = parts[1]
+			res[FieldSpanID] = parts[2]
+		}
+	}
+	for key, field := range GRPCMetadataKeys {
+		if v := md.Get(key); len(v) > 0 && v[0] != "" {
+			res[field] = v[0]
+		}
+	}
+	return res
+}
+
+var contextFieldNames = []string{FieldTraceID, FieldSpanID, FieldRequestID, FieldUserID}
+
+func init() {
+	RegisterContextExtractor("context", func(ctx context.Context) []zap.Field {
+		var res []zap.Field
+		for _, name := range contextFieldNames {
+			if v := ctxValue(ctx, name); v != "" {
+				res = append(res, zap.String(name, v))
+			}
+		}
+		return res
+	})
+	RegisterContextExtractor("grpc", func(ctx context.Context) []zap.Field {
+		md := grpcMetadata(ctx)
+		if len(md) == 0 {
+			return nil
+		}
+		var res []zap.Field
+		for _, name := range contextFieldNames {
+			if v, ok := md[name]; ok {
+				res = append(res, zap.String(name, v))
+			}
+		}
+		return res
+	})
+}
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestCtx(t *testing.T) {
+	out := &bytes.Buffer{}
+	registerTestHandlerType(t, "test_ctx", func(node *RawNode) (Handler, error) {
+		return &testSinkHandler{Level: zapcore.DebugLevel, out: out}, nil
+	})
+	type tenantKey struct{}
+	RegisterContextExtractor("test_tenant", func(ctx context.Context) []zap.Field {
+		if v, ok := ctx.Value(tenantKey{}).(string); ok {
+			return []zap.Field{zap.String("tenant", v)}
+		}
+		return nil
+	})
+	t.Cleanup(func() {
+		extractorsMu.Lock()
+		defer extractorsMu.Unlock()
+		for i, item := range extractors {
+			if item.name == "test_tenant" {
+				extractors = append(extractors[:i], extractors[i+1:]...)
+				break
+			}
+		}
+	})
+	YamlInit([]byte(`logging:
+  ctx:
+    handler:
+      - typ: test_ctx`))
+
+	md := metadata.Pairs(
+		"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"x-request-id", "req-from-md",
+		"x-user-id", "u1",
+	)
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	ctx = WithRequestID(ctx, "req-1") // 显式设置的值优先
+	ctx = context.WithValue(ctx, tenantKey{}, "acme")
+	Ctx(ctx, "ctx").Info("hello")
+	res := out.String()
+	for _, want := range []string{
+		`"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`,
+		`"span_id":"00f067aa0ba902b7"`,
+		`"request_id":"req-1"`,
+		`"user_id":"u1"`,
+		`"tenant":"acme"`,
+	} {
+		if !strings.Contains(res, want) {
+			t.Errorf("%s not found: %s", want, res)
+		}
+	}
+	if strings.Contains(res, "req-from-md") {
+		t.Errorf("request_id should be overridden: %s", res)
+	}
+	if TraceID(ctx) != "4bf92f3577b34da6a3ce929d0e0e4736" || RequestID(ctx) != "req-1" {
+		t.Errorf("trace_id=%s, request_id=%s", TraceID(ctx), RequestID(ctx))
+	}
+
+	out.Reset()
+	Ctx(context.Background(), "ctx").Info("plain")
+	if strings.Contains(out.String(), "trace_id") {
+		t.Errorf("unexpected fields: %s", out.String())
+	}
+}
@@ -0,0 +1,402 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 16:10
+// @File   : encoder.go
+// @Project: utils/logger
+// ==========================
+
+// EncoderConfig yaml 中 handler.encoder 的配置, 未填写的项与 ConsoleFormatter/JsonFormatter 一致.
+type EncoderConfig struct {
+	TimeKey       string `yaml:"time_key"`
+	LevelKey      string `yaml:"level_key"`
+	MessageKey    string `yaml:"message_key"`
+	CallerKey     string `yaml:"caller_key"`
+	NameKey       string `yaml:"name_key"`
+	StacktraceKey string `yaml:"stacktrace_key"`
+
+	TimeFormat     string `yaml:"time_format"`     // iso8601(默认) | rfc3339 | rfc3339nano | epoch | epoch_millis | epoch_nanos | 自定义 layout
+	LevelFormat    string `yaml:"level_format"`    // lower(默认) | capital | color | capital_color
+	DurationFormat string `yaml:"duration_format"` // nanos(默认) | millis | seconds | string
+	CallerFormat   string `yaml:"caller_format"`   // short(默认) | full
+}
+
+var levelEncoderMap = map[string]zapcore.LevelEncoder{
+	"lower":         zapcore.LowercaseLevelEncoder,
+	"capital":       zapcore.CapitalLevelEncoder,
+	"color":         zapcore.LowercaseColorLevelEncoder,
+	"capital_color": zapcore.CapitalColorLevelEncoder,
+}
+
+var durationEncoderMap = map[string]zapcore.DurationEncoder{
+	"nanos":   zapcore.NanosDurationEncoder,
+	"millis":  zapcore.MillisDurationEncoder,
+	"seconds": zapcore.SecondsDurationEncoder,
+	"string":  zapcore.StringDurationEncoder,
+}
+
+var callerEncoderMap = map[string]zapcore.CallerEncoder{
+	"short": zapcore.ShortCallerEncoder,
+	"full":  zapcore.FullCallerEncoder,
+}
+
+var timeEncoderMap = map[string]zapcore.TimeEncoder{
+	"iso8601":      zapcore.ISO8601TimeEncoder,
+	"rfc3339":      zapcore.RFC3339TimeEncoder,
+	"rfc3339nano":  zapcore.RFC3339NanoTimeEncoder,
+	"epoch":        zapcore.EpochTimeEncoder,
+	"epoch_millis": zapcore.EpochMillisTimeEncoder,
+	"epoch_nanos":  zapcore.EpochNanosTimeEncoder,
+}
+
+// ZapConfig 转换为 zapcore.EncoderConfig.
+func (c *EncoderConfig) ZapConfig() (zapcore.EncoderConfig, error) {
+	res := consoleEncoderConfig
+	if c == nil {
+		return res, nil
+	}
+	for _, item := range []struct {
+		dst *string
+		src string
+	}{
+		{&res.TimeKey, c.TimeKey},
+		{&res.LevelKey, c.LevelKey},
+		{&res.MessageKey, c.MessageKey},
+		{&res.CallerKey, c.CallerKey},
+		{&res.NameKey, c.NameKey},
+		{&res.StacktraceKey, c.StacktraceKey},
+	} {
+		if item.src != "" {
+			*item.dst = item.src
+		}
+	}
+	if c.TimeFormat != "" {
+		if enc, ok := timeEncoderMap[c.TimeFormat]; ok {
+			res.EncodeTime = enc
+		} else {
+			res.EncodeTime = zapcore.TimeEncoderOfLayout(c.TimeFormat)
+		}
+	}
+	if c.LevelFormat != "" {
+		enc, ok := levelEncoderMap[c.LevelFormat]
+		if !ok {
+			return res, errors.New("invalid encoder level_format: " + c.LevelFormat)
+		}
+		res.EncodeLevel = enc
+	}
+	if c.DurationFormat != "" {
+		enc, ok := durationEncoderMap[c.DurationFormat]
+		if !ok {
+			return res, errors.New("invalid encoder duration_format: " + c.DurationFormat)
+		}
+		res.EncodeDuration = enc
+	}
+	if c.CallerFormat != "" {
+		enc, ok := callerEncoderMap[c.CallerFormat]
+		if !ok {
+			return res, errors.New("invalid encoder caller_format: " + c.CallerFormat)
+		}
+		res.EncodeCaller = enc
+	}
+	return res, nil
+}
+
+// NewEncoder 根据格式(console | json | logfmt)和 encoder 配置创建 encoder.
+// cfg 为 nil 时返回全局的 ConsoleFormatter/JsonFormatter/LogfmtFormatter.
+func NewEncoder(format string, cfg *EncoderConfig) (zapcore.Encoder, error) {
+	if cfg == nil {
+		return FormatEncoder(format), nil
+	}
+	ec, err := cfg.ZapConfig()
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case "json":
+		return zapcore.NewJSONEncoder(ec), nil
+	case "logfmt":
+		return NewLogfmtEncoder(ec), nil
+	default:
+		return zapcore.NewConsoleEncoder(ec), nil
+	}
+}
+
+var LogfmtFormatter = NewLogfmtEncoder(consoleEncoderConfig)
+
+// logfmtEncoder 输出 key=value 形式的日志, 包含空格, 引号, = 等字符的值会加引号转义.
+// 嵌套的对象和数组以 json 字符串的形式输出.
+type logfmtEncoder struct {
+	*zapcore.EncoderConfig
+	buf       *buffer.Buffer
+	namespace string
+}
+
+var bufferPool = buffer.NewPool()
+
+func NewLogfmtEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtEncoder{EncoderConfig: &cfg, buf: bufferPool.Get()}
+}
+
+func (enc *logfmtEncoder) Clone() zapcore.Encoder {
+	clone := &logfmtEncoder{EncoderConfig: enc.EncoderConfig, buf: bufferPool.Get(), namespace: enc.namespace}
+	clone.buf.Write(enc.buf.Bytes())
+	return clone
+}
+
+func (enc *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line := bufferPool.Get()
+	header := &logfmtEncoder{EncoderConfig: enc.EncoderConfig, buf: line}
+	if enc.TimeKey != "" && enc.EncodeTime != nil {
+		header.addPrimitive(enc.TimeKey, func(arr zapcore.PrimitiveArrayEncoder) { enc.EncodeTime(ent.Time, arr) })
+	}
+	if enc.LevelKey != "" && enc.EncodeLevel != nil {
+		header.addPrimitive(enc.LevelKey, func(arr zapcore.PrimitiveArrayEncoder) { enc.EncodeLevel(ent.Level, arr) })
+	}
+	if ent.LoggerName != "" && enc.NameKey != "" {
+		header.AddString(enc.NameKey, ent.LoggerName)
+	}
+	if ent.Caller.Defined && enc.CallerKey != "" && enc.EncodeCaller != nil {
+		header.addPrimitive(enc.CallerKey, func(arr zapcore.PrimitiveArrayEncoder) { enc.EncodeCaller(ent.Caller, arr) })
+	}
+	if enc.MessageKey != "" {
+		header.AddString(enc.MessageKey, ent.Message)
+	}
+	if enc.buf.Len() > 0 {
+		if line.Len() > 0 {
+			line.AppendByte(' ')
+		}
+		line.Write(enc.buf.Bytes())
+	}
+	body := &logfmtEncoder{EncoderConfig: enc.EncoderConfig, buf: line, namespace: enc.namespace}
+	for i := range fields {
+		fields[i].AddTo(body)
+	}
+	if ent.Stack != "" && enc.StacktraceKey != "" {
+		header.AddString(enc.StacktraceKey, ent.Stack)
+	}
+	if enc.LineEnding != "" {
+		line.AppendString(enc.LineEnding)
+	} else {
+		line.AppendString(zapcore.DefaultLineEnding)
+	}
+	return line, nil
+}
+
+func (enc *logfmtEncoder) addKey(key string) {
+	if enc.buf.Len() > 0 {
+		enc.buf.AppendByte(' ')
+	}
+	if enc.namespace != "" {
+		enc.buf.AppendString(enc.namespace)
+		enc.buf.AppendByte('.')
+	}
+	enc.buf.AppendString(key)
+	enc.buf.AppendByte('=')
+}
+
+func (enc *logfmtEncoder) appendValue(value string) {
+	if needQuote(value) {
+		enc.buf.AppendString(strconv.Quote(value))
+	} else {
+		enc.buf.AppendString(value)
+	}
+}
+
+func needQuote(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// addPrimitive 使用 zap 的 TimeEncoder/LevelEncoder 等编码单个值.
+func (enc *logfmtEncoder) addPrimitive(key string, fn func(zapcore.PrimitiveArrayEncoder)) {
+	arr := &primitiveCapture{}
+	fn(arr)
+	enc.addKey(key)
+	enc.appendValue(strings.Join(arr.values, ","))
+}
+
+func (enc *logfmtEncoder) addJSON(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	enc.addKey(key)
+	enc.appendValue(string(data))
+	return nil
+}
+
+func (enc *logfmtEncoder) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	m := zapcore.NewMapObjectEncoder()
+	if err := m.AddArray(key, arr); err != nil {
+		return err
+	}
+	return enc.addJSON(key, m.Fields[key])
+}
+
+func (enc *logfmtEncoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	m := zapcore.NewMapObjectEncoder()
+	if err := obj.MarshalLogObject(m); err != nil {
+		return err
+	}
+	return enc.addJSON(key, m.Fields)
+}
+
+func (enc *logfmtEncoder) AddBinary(key string, value []byte) {
+	enc.AddString(key, base64.StdEncoding.EncodeToString(value))
+}
+
+func (enc *logfmtEncoder) AddByteString(key string, value []byte) {
+	enc.AddString(key, string(value))
+}
+
+func (enc *logfmtEncoder) AddBool(key string, value bool) {
+	enc.addKey(key)
+	enc.buf.AppendBool(value)
+}
+
+func (enc *logfmtEncoder) AddComplex128(key string, value complex128) {
+	enc.AddString(key, strconv.FormatComplex(value, 'g', -1, 128))
+}
+
+func (enc *logfmtEncoder) AddComplex64(key string, value complex64) {
+	enc.AddString(key, strconv.FormatComplex(complex128(value), 'g', -1, 64))
+}
+
+func (enc *logfmtEncoder) AddDuration(key string, value time.Duration) {
+	if enc.EncodeDuration == nil {
+		enc.AddInt64(key, int64(value))
+		return
+	}
+	enc.addPrimitive(key, func(arr zapcore.PrimitiveArrayEncoder) { enc.EncodeDuration(value, arr) })
+}
+
+func (enc *logfmtEncoder) AddFloat64(key string, value float64) {
+	enc.addKey(key)
+	enc.appendValue(formatFloat(value, 64))
+}
+
+func (enc *logfmtEncoder) AddFloat32(key string, value float32) {
+	enc.addKey(key)
+	enc.appendValue(formatFloat(float64(value), 32))
+}
+
+func formatFloat(value float64, bitSize int) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'f', -1, bitSize)
+}
+
+func (enc *logfmtEncoder) AddInt(key string, value int)     { enc.AddInt64(key, int64(value)) }
+func (enc *logfmtEncoder) AddInt32(key string, value int32) { enc.AddInt64(key, int64(value)) }
+func (enc *logfmtEncoder) AddInt16(key string, value int16) { enc.AddInt64(key, int64(value)) }
+func (enc *logfmtEncoder) AddInt8(key string, value int8)   { enc.AddInt64(key, int64(value)) }
+
+func (enc *logfmtEncoder) AddInt64(key string, value int64) {
+	enc.addKey(key)
+	enc.buf.AppendInt(value)
+}
+
+func (enc *logfmtEncoder) AddString(key, value string) {
+	enc.addKey(key)
+	enc.appendValue(value)
+}
+
+func (enc *logfmtEncoder) AddTime(key string, value time.Time) {
+	if enc.EncodeTime == nil {
+		enc.AddString(key, value.Format(time.RFC3339Nano))
+		return
+	}
+	enc.addPrimitive(key, func(arr zapcore.PrimitiveArrayEncoder) { enc.EncodeTime(value, arr) })
+}
+
+func (enc *logfmtEncoder) AddUint(key string, value uint)       { enc.AddUint64(key, uint64(value)) }
+func (enc *logfmtEncoder) AddUint32(key string, value uint32)   { enc.AddUint64(key, uint64(value)) }
+func (enc *logfmtEncoder) AddUint16(key string, value uint16)   { enc.AddUint64(key, uint64(value)) }
+func (enc *logfmtEncoder) AddUint8(key string, value uint8)     { enc.AddUint64(key, uint64(value)) }
+func (enc *logfmtEncoder) AddUintptr(key string, value uintptr) { enc.AddUint64(key, uint64(value)) }
+
+func (enc *logfmtEncoder) AddUint64(key string, value uint64) {
+	enc.addKey(key)
+	enc.buf.AppendUint(value)
+}
+
+func (enc *logfmtEncoder) AddReflected(key string, value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		enc.AddString(key, v)
+		return nil
+	case fmt.Stringer:
+		enc.AddString(key, v.String())
+		return nil
+	}
+	return enc.addJSON(key, value)
+}
+
+func (enc *logfmtEncoder) OpenNamespace(key string) {
+	if enc.namespace == "" {
+		enc.namespace = key
+	} else {
+		enc.namespace += "." + key
+	}
+}
+
+// primitiveCapture 以字符串形式记录 zap 的 TimeEncoder 等写入的值.
+type primitiveCapture struct {
+	values []string
+}
+
+func (p *primitiveCapture) add(v string) { p.values = append(p.values, v) }
+
+func (p *primitiveCapture) AppendBool(v bool)         { p.add(strconv.FormatBool(v)) }
+func (p *primitiveCapture) AppendByteString(v []byte) { p.add(string(v)) }
+func (p *primitiveCapture) AppendComplex128(v complex128) {
+	p.add(strconv.FormatComplex(v, 'g', -1, 128))
+}
+func (p *primitiveCapture) AppendComplex64(v complex64) {
+	p.add(strconv.FormatComplex(complex128(v), 'g', -1, 64))
+}
+func (p *primitiveCapture) AppendFloat64(v float64)        { p.add(formatFloat(v, 64)) }
+func (p *primitiveCapture) AppendFloat32(v float32)        { p.add(formatFloat(float64(v), 32)) }
+func (p *primitiveCapture) AppendInt(v int)                { p.add(strconv.Itoa(v)) }
+func (p *primitiveCapture) AppendInt64(v int64)            { p.add(strconv.FormatInt(v, 10)) }
+func (p *primitiveCapture) AppendInt32(v int32)            { p.add(strconv.FormatInt(int64(v), 10)) }
+func (p *primitiveCapture) AppendInt16(v int16)            { p.add(strconv.FormatInt(int64(v), 10)) }
+func (p *primitiveCapture) AppendInt8(v int8)              { p.add(strconv.FormatInt(int64(v), 10)) }
+func (p *primitiveCapture) AppendString(v string)          { p.add(v) }
+func (p *primitiveCapture) AppendUint(v uint)              { p.add(strconv.FormatUint(uint64(v), 10)) }
+func (p *primitiveCapture) AppendUint64(v uint64)          { p.add(strconv.FormatUint(v, 10)) }
+func (p *primitiveCapture) AppendUint32(v uint32)          { p.add(strconv.FormatUint(uint64(v), 10)) }
+func (p *primitiveCapture) AppendUint16(v uint16)          { p.add(strconv.FormatUint(uint64(v), 10)) }
+func (p *primitiveCapture) AppendUint8(v uint8)            { p.add(strconv.FormatUint(uint64(v), 10)) }
+func (p *primitiveCapture) AppendUintptr(v uintptr)        { p.add(strconv.FormatUint(uint64(v), 10)) }
+func (p *primitiveCapture) AppendDuration(v time.Duration) { p.add(v.String()) }
+func (p *primitiveCapture) AppendTime(v time.Time)         { p.add(v.Format(time.RFC3339Nano)) }
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogfmtEncoder(t *testing.T) {
+	cfg := &EncoderConfig{TimeFormat: "epoch_millis", DurationFormat: "string"}
+	enc, err := NewEncoder("logfmt", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc = enc.Clone()
+	enc.AddString("module", "payment")
+	ent := zapcore.Entry{Level: zapcore.WarnLevel, Time: time.Unix(1, 500000000), Message: "slow call"}
+	buf, err := enc.EncodeEntry(ent, []zapcore.Field{
+		zap.Duration("cost", 1500*time.Millisecond),
+		zap.String("path", "/a b"),
+		zap.Int("n", 3),
+		zap.Strings("tags", []string{"x", "y"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `ts_=1500 level_=warn msg_="slow call" module=payment cost=1.5s path="/a b" n=3 tags="[\"x\",\"y\"]"` + "\n"
+	if buf.String() != want {
+		t.Errorf("got  %q\nwant %q", buf.String(), want)
+	}
+}
+
+func TestEncoderConfig(t *testing.T) {
+	enc, err := NewEncoder("json", &EncoderConfig{TimeKey: "@timestamp", LevelKey: "level", MessageKey: "message", TimeFormat: "rfc3339nano", LevelFormat: "capital"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ent := zapcore.Entry{Level: zapcore.ErrorLevel, Time: time.Date(2024, 1, 1, 0, 0, 0, 1, time.UTC), Message: "boom"}
+	buf, _ := enc.EncodeEntry(ent, nil)
+	want := `{"level":"ERROR","@timestamp":"2024-01-01T00:00:00.000000001Z","message":"boom"}`
+	if strings.TrimSpace(buf.String()) != want {
+		t.Errorf("got %s, want %s", buf.String(), want)
+	}
+	if _, err := NewEncoder("json", &EncoderConfig{LevelFormat: "upper"}); err == nil {
+		t.Errorf("invalid level_format should return error")
+	}
+}
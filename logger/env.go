@@ -0,0 +1,226 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 19:40
+// @File   : env.go
+// @Project: utils/logger
+// ==========================
+
+// EnvPrefix 覆盖 yaml 配置的环境变量前缀, 格式:
+//
+//	LOGGING_<MODULE>_<KEY>            module 级别的配置, 如 LOGGING_APP_CALLER=false
+//	LOGGING_<MODULE>_<HANDLER>_<KEY>  handler 的配置, HANDLER 为 handler 的 name 或下标, 如 LOGGING_APP_0_LEVEL=info, LOGGING_APP_STDOUT_LEVEL=info
+//
+// MODULE, HANDLER 为大写, 非字母数字的字符替换为 _. KEY 为 yaml 中的 key 的大写.
+// 值按 yaml 解析, 如 LOGGING_APP_0_FILTER='{levels: [error]}'; 需要字符串时加引号, 如 LOGGING_APP_0_FILENAME='"on"'.
+// 只能覆盖 yaml 中已有的 module 和 handler, HANDLER 不存在或 KEY 不是 module 的配置项时返回错误.
+const EnvPrefix = "LOGGING_"
+
+// DefaultYAML 没有配置(空内容)时使用的默认配置: module app 输出到标准输出.
+var DefaultYAML = []byte(`logging:
+  app:
+    handler:
+      - typ: file
+        name: stdout
+        filename: "/dev/stdout"
+        format: "${LOG_FORMAT:-console}"
+        level: "${LOG_LEVEL:-debug}"
+    caller: true`)
+
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// ExpandEnv 替换 ${VAR} 和 ${VAR:-default}, VAR 未设置或为空时使用 default. 在解析 yaml 之前按文本替换.
+func ExpandEnv(data []byte) []byte {
+	return envPattern.ReplaceAllFunc(data, func(m []byte) []byte {
+		sub := envPattern.FindSubmatch(m)
+		if v := os.Getenv(string(sub[1])); v != "" {
+			return []byte(v)
+		}
+		return sub[3]
+	})
+}
+
+// ResolveConfig 返回实际生效的 yaml 配置: 内容为空时使用 DefaultYAML, 替换环境变量, 最后用 LOGGING_ 开头的环境变量覆盖.
+func ResolveConfig(data []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = DefaultYAML
+	}
+	return overlayEnv(ExpandEnv(data), os.Environ())
+}
+
+// ParseConfig 解析 ResolveConfig 之后的 yaml 配置.
+func ParseConfig(data []byte) (*Config, error) {
+	data, err := ResolveConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// envName 转换为环境变量中的格式.
+func envName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+func mapGet(m yaml.MapSlice, key string) (interface{}, bool) {
+	for _, item := range m {
+		if k, ok := item.Key.(string); ok && k == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+func mapSet(m yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i, item := range m {
+		if k, ok := item.Key.(string); ok && k == key {
+			m[i].Value = value
+			return m
+		}
+	}
+	return append(m, yaml.MapItem{Key: key, Value: value})
+}
+
+func envValue(s string) interface{} {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil || v == nil {
+		return s
+	}
+	return v
+}
+
+// overlayEnv 用 LOGGING_ 开头的环境变量覆盖配置.
+func overlayEnv(data []byte, environ []string) ([]byte, error) {
+	var overrides []string
+	for _, kv := range environ {
+		if strings.HasPrefix(kv, EnvPrefix) && strings.Contains(kv, "=") {
+			overrides = append(overrides, kv)
+		}
+	}
+	if len(overrides) == 0 {
+		return data, nil
+	}
+	var root yaml.MapSlice
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	v, _ := mapGet(root, "logging")
+	modules, ok := v.(yaml.MapSlice)
+	if !ok {
+		return data, nil
+	}
+	// module 名称按长度倒序, 优先匹配更长的名称, 如 APP_WORKER 优先于 APP.
+	names := make([]string, 0, len(modules))
+	for _, item := range modules {
+		if name, ok := item.Key.(string); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	sort.Strings(overrides) // 保证结果稳定
+
+	changed := false
+	for _, kv := range overrides {
+		i := strings.Index(kv, "=")
+		key, value := kv[len(EnvPrefix):i], kv[i+1:]
+		for _, name := range names {
+			prefix := envName(name) + "_"
+			if !strings.HasPrefix(key, prefix) || len(key) == len(prefix) {
+				continue
+			}
+			rest := key[len(prefix):]
+			v, _ := mapGet(modules, name)
+			module, _ := v.(yaml.MapSlice)
+			matched, err := applyHandlerEnv(module, rest, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s%s: %v (module %s)", EnvPrefix, key, err, name)
+			}
+			if !matched {
+				if err := checkModuleEnv(module, rest); err != nil {
+					return nil, fmt.Errorf("%s%s: %v (module %s)", EnvPrefix, key, err, name)
+				}
+				modules = mapSet(modules, name, mapSet(module, strings.ToLower(rest), envValue(value)))
+			}
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return data, nil
+	}
+	root = mapSet(root, "logging", modules)
+	return yaml.Marshal(root)
+}
+
+// checkModuleEnv rest 不是 <HANDLER>_<KEY> 时, 检查是否为 module 级别的 key, 避免把不存在的 handler 当作 module 的配置.
+func checkModuleEnv(module yaml.MapSlice, rest string) error {
+	if yamlKeys(reflect.TypeOf(ConfigHandler{}))[strings.ToLower(rest)] {
+		return nil
+	}
+	if i := strings.Index(rest, "_"); i > 0 {
+		if index, err := strconv.Atoi(rest[:i]); err == nil {
+			v, _ := mapGet(module, "handler")
+			handlers, _ := v.([]interface{})
+			return fmt.Errorf("handler index %d out of range, %d handlers", index, len(handlers))
+		}
+	}
+	return fmt.Errorf("no handler or module key matches %s", rest)
+}
+
+// applyHandlerEnv rest 为 <HANDLER>_<KEY> 时修改对应的 handler, 返回是否匹配.
+// 内置类型的 handler 只允许 HandlerConfig 中的 key, 注册的类型由其 factory 解析, 不检查.
+func applyHandlerEnv(module yaml.MapSlice, rest, value string) (bool, error) {
+	v, _ := mapGet(module, "handler")
+	handlers, _ := v.([]interface{})
+	for i, h := range handlers {
+		handler, ok := h.(yaml.MapSlice)
+		if !ok {
+			continue
+		}
+		ids := []string{strconv.Itoa(i)}
+		if name, ok := mapGet(handler, "name"); ok {
+			if s, ok := name.(string); ok && s != "" {
+				ids = append(ids, envName(s))
+			}
+		}
+		for _, id := range ids {
+			prefix := id + "_"
+			if strings.HasPrefix(rest, prefix) && len(rest) > len(prefix) {
+				key := strings.ToLower(rest[len(prefix):])
+				typ, _ := mapGet(handler, "typ")
+				if name, _ := typ.(string); builtinHandlerTypes[name] && !yamlKeys(reflect.TypeOf(HandlerConfig{}))[key] {
+					return false, fmt.Errorf("unknown handler key %s", key)
+				}
+				handlers[i] = mapSet(handler, key, envValue(value))
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
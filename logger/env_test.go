@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("TEST_LOG_DIR", "/var/log/app")
+	t.Setenv("TEST_LOG_EMPTY", "")
+	data := ExpandEnv([]byte(`filename: "${TEST_LOG_DIR}/access.log"
+replica: ${TEST_LOG_REPLICA:-3}
+level: ${TEST_LOG_EMPTY:-info}
+raw: $HOME ${}`))
+	want := `filename: "/var/log/app/access.log"
+replica: 3
+level: info
+raw: $HOME ${}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestOverlayEnv(t *testing.T) {
+	t.Setenv("TEST_ENV_LEVEL", "warn")
+	t.Setenv("LOGGING_ENV_APP_STDOUT_LEVEL", "error")
+	t.Setenv("LOGGING_ENV_APP_1_FILTER", "{levels: [error]}")
+	t.Setenv("LOGGING_ENV_APP_CALLER", "false")
+	t.Setenv("LOGGING_ENV_STACKTRACE_LEVEL", "error")
+	t.Setenv("LOGGING_UNKNOWN_0_LEVEL", "info")
+	cfg, err := ParseConfig([]byte(`logging:
+  env:
+    handler:
+      - typ: memory
+        level: ${TEST_ENV_LEVEL:-debug}
+  env_app:
+    handler:
+      - typ: memory
+        name: stdout
+        level: debug
+      - typ: memory
+        level: debug
+    caller: true`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, app := cfg.Logging["env"], cfg.Logging["env_app"]
+	if env.Handler[0].Level != "warn" || env.StacktraceLevel != "error" {
+		t.Errorf("env=%+v", env)
+	}
+	if app.Caller || app.Handler[0].Level != "error" || app.Handler[1].Filter == nil || strings.Join(app.Handler[1].Filter.Levels, ",") != "error" {
+		t.Errorf("env_app=%+v", app)
+	}
+	if _, ok := cfg.Logging["unknown"]; ok {
+		t.Error("unknown module should not be added")
+	}
+}
+
+func TestOverlayEnvUnknownHandler(t *testing.T) {
+	data := []byte(`logging:
+  app:
+    handler:
+      - typ: memory
+        name: stdout
+      - typ: memory`)
+	for _, c := range []struct {
+		env, err string
+	}{
+		{"LOGGING_APP_5_LEVEL=info", "handler index 5 out of range, 2 handlers"},
+		{"LOGGING_APP_STDERR_LEVEL=info", "no handler or module key matches STDERR_LEVEL"},
+		{"LOGGING_APP_0_LEVLE=info", "LOGGING_APP_0_LEVLE: unknown handler key levle (module app)"},
+		{"LOGGING_APP_STDOUT_LEVLE=info", "unknown handler key levle"},
+	} {
+		_, err := overlayEnv(data, []string{c.env})
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: err=%v, want %q", c.env, err, c.err)
+		}
+	}
+	if _, err := overlayEnv(data, []string{"LOGGING_APP_1_LEVEL=info", "LOGGING_APP_CALLER_SKIP=1"}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDefaultProfile(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "info")
+	t.Setenv("LOGGING_APP_STDOUT_FORMAT", "json")
+	cfg, err := ParseConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app, ok := cfg.Logging["app"]
+	if !ok || len(app.Handler) != 1 {
+		t.Fatalf("default profile=%+v", cfg)
+	}
+	if h := app.Handler[0]; h.Filename != "/dev/stdout" || h.Level != "info" || h.Format != "json" {
+		t.Errorf("default handler=%+v", h)
+	}
+}
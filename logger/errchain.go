@@ -0,0 +1,152 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 18:10
+// @File   : errchain.go
+// @Project: utils/logger
+// ==========================
+
+// maxErrorDepth 防止循环引用的 error 无限展开.
+const maxErrorDepth = 32
+
+// ErrorChain 把 err 及其 cause(errors.Unwrap, Cause()) 逐层展开为结构化字段:
+//
+//	{"msg": "...", "chain": [{"type": "*grpc_error.AppError", "msg": "...", "code": "E400", ...}, {"type": "*errors.errorString", "msg": "EOF"}]}
+//
+// 实现了 zapcore.ObjectMarshaler 的 error(如 *grpc_error.AppError) 会附带自身的字段. errors.Join 的多个 cause 放在 errors 中.
+func ErrorChain(key string, err error) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.Object(key, errorChain{err: err})
+}
+
+// Err 即 ErrorChain("error", err).
+func Err(err error) zap.Field {
+	return ErrorChain("error", err)
+}
+
+type errorChain struct {
+	err error
+}
+
+func (c errorChain) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("msg", c.err.Error())
+	return enc.AddArray("chain", causes{err: c.err})
+}
+
+type causes struct {
+	err   error
+	depth int
+}
+
+func (c causes) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	err := c.err
+	for depth := c.depth; err != nil && depth < maxErrorDepth; depth++ {
+		item := cause{err: err, depth: depth}
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			item.joined = multi.Unwrap()
+		}
+		if e := enc.AppendObject(item); e != nil {
+			return e
+		}
+		if item.joined != nil {
+			break
+		}
+		err = unwrap(err)
+	}
+	return nil
+}
+
+type cause struct {
+	err    error
+	joined []error
+	depth  int
+}
+
+func (c cause) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("type", fmt.Sprintf("%T", c.err))
+	enc.AddString("msg", c.err.Error())
+	if m, ok := c.err.(zapcore.ObjectMarshaler); ok {
+		if err := m.MarshalLogObject(enc); err != nil {
+			return err
+		}
+	}
+	if len(c.joined) > 0 {
+		return enc.AddArray("errors", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+			for _, err := range c.joined {
+				if err == nil {
+					continue
+				}
+				if e := arr.AppendArray(causes{err: err, depth: c.depth + 1}); e != nil {
+					return e
+				}
+			}
+			return nil
+		}))
+	}
+	return nil
+}
+
+// unwrap 返回 err 的下一层 cause, 兼容 pkg/errors 风格的 Cause().
+func unwrap(err error) error {
+	if next := errors.Unwrap(err); next != nil {
+		return next
+	}
+	if c, ok := err.(interface{ Cause() error }); ok {
+		return c.Cause()
+	}
+	return nil
+}
+
+// errorChainCore 把 zap.Error 等 error 类型的字段替换为 ErrorChain.
+type errorChainCore struct {
+	zapcore.Core
+}
+
+func errorChainWrap(core zapcore.Core) zapcore.Core {
+	return &errorChainCore{Core: core}
+}
+
+func errorChainFields(fields []zapcore.Field) []zapcore.Field {
+	var res []zapcore.Field
+	for i, f := range fields {
+		if f.Type == zapcore.ErrorType {
+			if err, ok := f.Interface.(error); ok && err != nil {
+				if res == nil {
+					res = make([]zapcore.Field, len(fields))
+					copy(res, fields)
+				}
+				res[i] = ErrorChain(f.Key, err)
+			}
+		}
+	}
+	if res == nil {
+		return fields
+	}
+	return res
+}
+
+func (c *errorChainCore) With(fields []zapcore.Field) zapcore.Core {
+	return &errorChainCore{Core: c.Core.With(errorChainFields(fields))}
+}
+
+func (c *errorChainCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *errorChainCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, errorChainFields(fields))
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type testCodeError struct {
+	code string
+	err  error
+}
+
+func (e *testCodeError) Error() string { return e.code + ": " + e.err.Error() }
+func (e *testCodeError) Cause() error  { return e.err }
+func (e *testCodeError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("code", e.code)
+	return nil
+}
+
+func TestErrorChain(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := zap.New(zapcore.NewCore(JsonFormatter, zapcore.AddSync(out), zapcore.DebugLevel))
+	err := fmt.Errorf("load config: %w", &testCodeError{code: "E400", err: io.EOF})
+	l.Error("failed", Err(err))
+	var res struct {
+		Error struct {
+			Msg   string                   `json:"msg"`
+			Chain []map[string]interface{} `json:"chain"`
+		} `json:"error"`
+	}
+	if e := json.Unmarshal(out.Bytes(), &res); e != nil {
+		t.Fatal(e, out.String())
+	}
+	if res.Error.Msg != "load config: E400: EOF" || len(res.Error.Chain) != 3 {
+		t.Fatalf("unexpected chain: %s", out.String())
+	}
+	if res.Error.Chain[1]["code"] != "E400" || res.Error.Chain[1]["type"] != "*logger.testCodeError" || res.Error.Chain[2]["msg"] != "EOF" {
+		t.Errorf("unexpected chain: %s", out.String())
+	}
+
+	out.Reset()
+	l.Error("joined", Err(errors.Join(io.EOF, fmt.Errorf("wrap: %w", io.ErrUnexpectedEOF))))
+	if !strings.Contains(out.String(), `"errors":[[{"type":"*errors.errorString","msg":"EOF"}],[{"type":"*fmt.wrapError"`) {
+		t.Errorf("unexpected joined chain: %s", out.String())
+	}
+}
+
+func TestModuleStacktrace(t *testing.T) {
+	out := &bytes.Buffer{}
+	registerTestHandlerType(t, "test_stacktrace", func(node *RawNode) (Handler, error) {
+		return &testSinkHandler{Level: zapcore.DebugLevel, out: out}, nil
+	})
+	YamlInit([]byte(`logging:
+  stacktrace:
+    handler:
+      - typ: test_stacktrace
+    caller: true
+    caller_skip: 1
+    stacktrace_level: error
+    error_chain: true`))
+	logWrapped := func(msg string, err error) {
+		L("stacktrace").Error(msg, zap.Error(err))
+	}
+	logWrapped("wrapped", fmt.Errorf("outer: %w", io.EOF))
+	_, _, line, _ := runtime.Caller(0)
+	res := out.String()
+	if !strings.Contains(res, `"trace_":`) {
+		t.Errorf("stacktrace not found: %s", res)
+	}
+	// caller_skip: 1 跳过 logWrapped, caller 应为本函数调用 logWrapped 的位置.
+	if !strings.Contains(res, fmt.Sprintf("errchain_test.go:%d", line-1)) {
+		t.Errorf("unexpected caller: %s", res)
+	}
+	if !strings.Contains(res, `"chain":[{"type":"*fmt.wrapError"`) {
+		t.Errorf("error chain not found: %s", res)
+	}
+	out.Reset()
+	L("stacktrace").Warn("warn")
+	if strings.Contains(out.String(), `"trace_":`) {
+		t.Errorf("unexpected stacktrace: %s", out.String())
+	}
+}
@@ -0,0 +1,284 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 18:40
+// @File   : filter.go
+// @Project: utils/logger
+// ==========================
+
+// FilterConfig yaml 中 handler.filter 的配置, 所有条件同时满足的日志才会输出. 所有类型通用.
+// handler 的 level 仍然生效, 等级条件只能在其基础上进一步缩小范围.
+type FilterConfig struct {
+	Levels   []string `yaml:"levels"`    // 只输出这些等级
+	MinLevel string   `yaml:"min_level"` // 等级范围, 包含两端
+	MaxLevel string   `yaml:"max_level"`
+
+	LoggerInclude string `yaml:"logger_include"` // logger 名称(zap.Logger.Named)正则
+	LoggerExclude string `yaml:"logger_exclude"`
+	MsgInclude    string `yaml:"msg_include"` // 日志内容正则
+	MsgExclude    string `yaml:"msg_exclude"`
+
+	// 字段条件, 包括 With 添加的字段:
+	// key=value 等于, key!=value 不等于, key~regex 匹配正则, key 存在, !key 不存在.
+	// 非字符串字段按 json 格式比较, 如 status=200.
+	Fields []string `yaml:"fields"`
+}
+
+type fieldPredicate struct {
+	key    string
+	op     string // "=" | "!=" | "~" | "exists" | "missing"
+	value  string
+	regexp *regexp.Regexp
+}
+
+func parseFieldPredicate(s string) (fieldPredicate, error) {
+	if strings.HasPrefix(s, "!") && len(s) > 1 && !strings.ContainsAny(s, "=~") {
+		return fieldPredicate{key: s[1:], op: "missing"}, nil
+	}
+	if i := strings.Index(s, "!="); i > 0 {
+		return fieldPredicate{key: s[:i], op: "!=", value: s[i+2:]}, nil
+	}
+	if i := strings.IndexAny(s, "=~"); i > 0 {
+		p := fieldPredicate{key: s[:i], op: s[i : i+1], value: s[i+1:]}
+		if p.op == "~" {
+			r, err := regexp.Compile(p.value)
+			if err != nil {
+				return p, fmt.Errorf("invalid filter field %q: %v", s, err)
+			}
+			p.regexp = r
+		}
+		return p, nil
+	}
+	if s == "" || strings.ContainsAny(s, "=~!") {
+		return fieldPredicate{}, fmt.Errorf("invalid filter field %q", s)
+	}
+	return fieldPredicate{key: s, op: "exists"}, nil
+}
+
+func (p fieldPredicate) match(values map[string]string) bool {
+	v, ok := values[p.key]
+	switch p.op {
+	case "exists":
+		return ok
+	case "missing":
+		return !ok
+	case "=":
+		return ok && v == p.value
+	case "!=":
+		return !ok || v != p.value
+	default:
+		return ok && p.regexp.MatchString(v)
+	}
+}
+
+// FieldMatcher 字段条件, 语法同 FilterConfig.Fields, 供 logq 等读取日志的工具使用.
+type FieldMatcher []fieldPredicate
+
+func ParseFieldMatcher(exprs []string) (FieldMatcher, error) {
+	m := make(FieldMatcher, 0, len(exprs))
+	for _, s := range exprs {
+		p, err := parseFieldPredicate(s)
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, p)
+	}
+	return m, nil
+}
+
+// Match values 为字段的字符串形式, 非字符串字段为 json 格式.
+func (m FieldMatcher) Match(values map[string]string) bool {
+	for _, p := range m {
+		if !p.match(values) {
+			return false
+		}
+	}
+	return true
+}
+
+type entryFilter struct {
+	levels                       map[zapcore.Level]bool
+	min, max                     zapcore.Level
+	loggerInclude, loggerExclude *regexp.Regexp
+	msgInclude, msgExclude       *regexp.Regexp
+	fields                       []fieldPredicate
+}
+
+func compileOptional(name, expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	r, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %s: %v", name, err)
+	}
+	return r, nil
+}
+
+func (c *FilterConfig) compile() (*entryFilter, error) {
+	f := &entryFilter{min: zapcore.DebugLevel, max: zapcore.FatalLevel}
+	for _, name := range c.Levels {
+		level, ok := LogLevelMap[name]
+		if !ok {
+			return nil, errors.New("invalid filter levels: " + name)
+		}
+		if f.levels == nil {
+			f.levels = map[zapcore.Level]bool{}
+		}
+		f.levels[level] = true
+	}
+	if c.MinLevel != "" {
+		level, ok := LogLevelMap[c.MinLevel]
+		if !ok {
+			return nil, errors.New("invalid filter min_level: " + c.MinLevel)
+		}
+		f.min = level
+	}
+	if c.MaxLevel != "" {
+		level, ok := LogLevelMap[c.MaxLevel]
+		if !ok {
+			return nil, errors.New("invalid filter max_level: " + c.MaxLevel)
+		}
+		f.max = level
+	}
+	if f.min > f.max {
+		return nil, errors.New("invalid filter: min_level > max_level")
+	}
+	var err error
+	if f.loggerInclude, err = compileOptional("logger_include", c.LoggerInclude); err != nil {
+		return nil, err
+	}
+	if f.loggerExclude, err = compileOptional("logger_exclude", c.LoggerExclude); err != nil {
+		return nil, err
+	}
+	if f.msgInclude, err = compileOptional("msg_include", c.MsgInclude); err != nil {
+		return nil, err
+	}
+	if f.msgExclude, err = compileOptional("msg_exclude", c.MsgExclude); err != nil {
+		return nil, err
+	}
+	for _, s := range c.Fields {
+		p, err := parseFieldPredicate(s)
+		if err != nil {
+			return nil, err
+		}
+		f.fields = append(f.fields, p)
+	}
+	return f, nil
+}
+
+func (f *entryFilter) enabled(level zapcore.Level) bool {
+	if f.levels != nil && !f.levels[level] {
+		return false
+	}
+	return level >= f.min && level <= f.max
+}
+
+func (f *entryFilter) match(ent zapcore.Entry) bool {
+	if !f.enabled(ent.Level) {
+		return false
+	}
+	if f.loggerInclude != nil && !f.loggerInclude.MatchString(ent.LoggerName) {
+		return false
+	}
+	if f.loggerExclude != nil && f.loggerExclude.MatchString(ent.LoggerName) {
+		return false
+	}
+	if f.msgInclude != nil && !f.msgInclude.MatchString(ent.Message) {
+		return false
+	}
+	if f.msgExclude != nil && f.msgExclude.MatchString(ent.Message) {
+		return false
+	}
+	return true
+}
+
+// filterWrap 返回包装 core 的函数.
+func (c *FilterConfig) filterWrap() (func(zapcore.Core) zapcore.Core, error) {
+	f, err := c.compile()
+	if err != nil {
+		return nil, err
+	}
+	return func(core zapcore.Core) zapcore.Core {
+		return &filterCore{Core: core, filter: f}
+	}, nil
+}
+
+// filterCore 按 FilterConfig 过滤日志. 等级, logger 名称和内容在 Check 时判断, 字段条件在 Write 时判断.
+type filterCore struct {
+	zapcore.Core
+	filter  *entryFilter
+	context map[string]string // With 添加的字段, 仅在有字段条件时记录
+}
+
+func (c *filterCore) Enabled(level zapcore.Level) bool {
+	return c.filter.enabled(level) && c.Core.Enabled(level)
+}
+
+func (c *filterCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &filterCore{Core: c.Core.With(fields), filter: c.filter, context: c.context}
+	if len(c.filter.fields) > 0 && len(fields) > 0 {
+		clone.context = make(map[string]string, len(c.context)+len(fields))
+		for k, v := range c.context {
+			clone.context[k] = v
+		}
+		for _, f := range fields {
+			clone.context[f.Key] = fieldString(f)
+		}
+	}
+	return clone
+}
+
+func (c *filterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.filter.match(ent) {
+		return ce
+	}
+	if len(c.filter.fields) == 0 {
+		return c.Core.Check(ent, ce)
+	}
+	// 字段只有在 Write 时才知道, 这里只判断等级, 字段条件满足后再由内层 core Check(如采样).
+	if !c.Core.Enabled(ent.Level) {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *filterCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.filter.match(ent) || !c.matchFields(fields) {
+		return nil
+	}
+	// 被字段条件过滤的日志不计入内层的采样.
+	if len(c.filter.fields) > 0 && c.Core.Check(ent, nil) == nil {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
+
+func (c *filterCore) matchFields(fields []zapcore.Field) bool {
+	if len(c.filter.fields) == 0 {
+		return true
+	}
+	values := make(map[string]string, len(c.context)+len(fields))
+	for k, v := range c.context {
+		values[k] = v
+	}
+	for _, f := range fields {
+		values[f.Key] = fieldString(f)
+	}
+	for _, p := range c.filter.fields {
+		if !p.match(values) {
+			return false
+		}
+	}
+	return true
+}
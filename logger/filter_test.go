@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestParseFieldPredicate(t *testing.T) {
+	values := map[string]string{"module": "payment", "status": "200"}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"module=payment", true},
+		{"module=order", false},
+		{"status!=500", true},
+		{"missing!=x", true},
+		{"module~^pay", true},
+		{"module", true},
+		{"user_id", false},
+		{"!user_id", true},
+		{"!module", false},
+	}
+	for _, tt := range tests {
+		p, err := parseFieldPredicate(tt.expr)
+		if err != nil {
+			t.Fatal(tt.expr, err)
+		}
+		if got := p.match(values); got != tt.want {
+			t.Errorf("%s match=%v, want %v", tt.expr, got, tt.want)
+		}
+	}
+	for _, expr := range []string{"", "=x", "a~(", "!"} {
+		if _, err := parseFieldPredicate(expr); err == nil {
+			t.Errorf("%q should be invalid", expr)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	outs := map[string]*bytes.Buffer{}
+	registerTestHandlerType(t, "test_filter", func(node *RawNode) (Handler, error) {
+		var cfg struct {
+			Name string `yaml:"name"`
+		}
+		if err := node.Decode(&cfg); err != nil {
+			return nil, err
+		}
+		outs[cfg.Name] = &bytes.Buffer{}
+		return &testSinkHandler{Level: zapcore.DebugLevel, out: outs[cfg.Name]}, nil
+	})
+	YamlInit([]byte(`logging:
+  filter:
+    handler:
+      - typ: test_filter
+        name: error_only
+        filter:
+          levels: [error]
+      - typ: test_filter
+        name: range
+        filter:
+          min_level: info
+          max_level: warn
+          msg_exclude: "^health"
+      - typ: test_filter
+        name: audit
+        filter:
+          logger_include: "^audit"
+          fields: ["module=payment", "status!=500"]
+        sampling:
+          tick: 1m
+          first: 1
+          thereafter: 0`))
+	l := L("filter")
+	l.Debug("debug")
+	l.Info("health check")
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+	audit := l.Named("audit").With(zap.String("module", "payment"))
+	audit.Info("paid", zap.Int("status", 200))
+	audit.Info("failed", zap.Int("status", 500))
+	audit.Info("paid", zap.Int("status", 200)) // 采样丢弃
+	// 被字段条件过滤的日志不计入采样.
+	audit.Info("refund", zap.Int("status", 500))
+	audit.Info("refund", zap.Int("status", 200))
+	l.Named("audit").Info("other module", zap.String("module", "order"))
+
+	check := func(name string, want ...string) {
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(outs[name].String()), "\n") {
+			if line == "" {
+				continue
+			}
+			for _, msg := range []string{"debug", "health check", "info", "warn", "error", "paid", "failed", "refund", "other module"} {
+				if strings.Contains(line, `"msg_":"`+msg+`"`) {
+					got = append(got, msg)
+					break
+				}
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%s got %v, want %v", name, got, want)
+		}
+	}
+	check("error_only", "error")
+	check("range", "info", "warn", "paid", "failed", "paid", "refund", "refund", "other module")
+	check("audit", "paid", "refund")
+}
+
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestFilterWriteError(t *testing.T) {
+	wrap, err := (&FilterConfig{Fields: []string{"module=payment"}}).filterWrap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	core := wrap(zapcore.NewCore(JsonFormatter.Clone(), zapcore.AddSync(failWriter{}), zapcore.DebugLevel))
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Message: "paid"}
+	ce := core.Check(ent, nil)
+	errOut := &bytes.Buffer{}
+	ce.ErrorOutput = zapcore.AddSync(errOut)
+	ce.Write(zap.String("module", "payment"))
+	if !strings.Contains(errOut.String(), "write error: disk full") || strings.Count(errOut.String(), "write error") != 1 {
+		t.Errorf("error output=%q", errOut.String())
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !unix
+
+package logger
+
+import "errors"
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 20:10
+// @File   : flock_other.go
+// @Project: utils/logger
+// ==========================
+
+type fileLock struct{}
+
+func lockFile(path string) (*fileLock, error) {
+	return nil, errors.New("shared rotate_file is not supported on this platform")
+}
+
+func (l *fileLock) Unlock() error {
+	return nil
+}
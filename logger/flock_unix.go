@@ -0,0 +1,43 @@
+//go:build unix
+
+package logger
+
+import (
+	"os"
+	"syscall"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 20:10
+// @File   : flock_unix.go
+// @Project: utils/logger
+// ==========================
+
+type fileLock struct {
+	f *os.File
+}
+
+// lockFile 对 path 加排他锁(flock), 阻塞直到获得锁.
+func lockFile(path string) (*fileLock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileLock{f: f}, nil
+}
+
+func (l *fileLock) Unlock() error {
+	defer l.f.Close()
+	return syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,364 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 22:40
+// @File   : grpc.go
+// @Project: utils/logger
+// ==========================
+
+// GRPCLogOptions grpc 请求日志的配置.
+type GRPCLogOptions struct {
+	Module string // 写入的 logger module
+
+	LogPayload bool         // 是否记录请求和响应内容(protojson), stream 只记录第一条消息
+	Redact     []RedactRule // 对记录的内容脱敏, 规则同 module.redact
+
+	// 耗时超过该值时等级提升一级(info -> warn -> error), 并附带 slow=true, 0 表示不判断.
+	SlowThreshold time.Duration
+
+	SkipHealthCheck bool     // 不记录 grpc.health.v1.Health 的请求
+	SkipMethods     []string // 不记录的方法, 如 /pkg.Service/Method
+}
+
+// GRPCCodeLevel 返回状态码对应的日志等级: OK 为 info, 调用方原因的错误为 warn, 其他为 error.
+func GRPCCodeLevel(code codes.Code) zapcore.Level {
+	switch code {
+	case codes.OK:
+		return zapcore.InfoLevel
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied,
+		codes.Unauthenticated, codes.FailedPrecondition, codes.OutOfRange, codes.ResourceExhausted:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+type grpcLogger struct {
+	opts     GRPCLogOptions
+	redactor *Redactor
+}
+
+func newGRPCLogger(opts GRPCLogOptions) *grpcLogger {
+	g := &grpcLogger{opts: opts}
+	if len(opts.Redact) > 0 {
+		r, err := NewRedactor(opts.Redact)
+		if err != nil {
+			panic("grpc log options: " + err.Error())
+		}
+		g.redactor = r
+	}
+	return g
+}
+
+func (g *grpcLogger) skip(fullMethod string) bool {
+	if g.opts.SkipHealthCheck && strings.HasPrefix(fullMethod, "/grpc.health.v1.Health/") {
+		return true
+	}
+	for _, m := range g.opts.SkipMethods {
+		if m == fullMethod {
+			return true
+		}
+	}
+	return false
+}
+
+// payload 返回消息的 protojson 内容(脱敏后), 非 proto 消息返回 nil.
+func (g *grpcLogger) payload(key string, msg interface{}) zap.Field {
+	m, ok := msg.(proto.Message)
+	if !ok || !g.opts.LogPayload {
+		return zap.Skip()
+	}
+	data, err := protojson.Marshal(m)
+	if err != nil {
+		return zap.String(key, "marshal error: "+err.Error())
+	}
+	var plain interface{}
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return zap.String(key, string(data))
+	}
+	if g.redactor != nil {
+		plain, _ = g.redactor.walk(plain)
+	}
+	return zap.Any(key, plain)
+}
+
+func messageSize(msg interface{}) int {
+	if m, ok := msg.(proto.Message); ok {
+		return proto.Size(m)
+	}
+	return 0
+}
+
+// log 写入一次调用的日志. payloads 不为 nil 时, 只在日志确实会写入(等级和采样)时调用, 避免为丢弃的日志序列化消息.
+func (g *grpcLogger) log(ctx context.Context, side, fullMethod string, start time.Time, err error, payloads func() []zap.Field, fields ...zap.Field) {
+	duration := time.Since(start)
+	code := status.Code(err)
+	level := GRPCCodeLevel(code)
+	service, method := path.Split(fullMethod)
+	fields = append(fields,
+		zap.String("grpc.side", side),
+		zap.String("grpc.service", strings.Trim(service, "/")),
+		zap.String("grpc.method", method),
+		zap.String("grpc.code", code.String()),
+		zap.Duration("grpc.duration", duration),
+	)
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields = append(fields, zap.String("peer.address", p.Addr.String()))
+	}
+	if err != nil {
+		fields = append(fields, zap.String("grpc.error", status.Convert(err).Message()))
+	}
+	if g.opts.SlowThreshold > 0 && duration >= g.opts.SlowThreshold {
+		fields = append(fields, zap.Bool("slow", true))
+		if level < zapcore.ErrorLevel {
+			level++
+		}
+	}
+	if ce := Ctx(ctx, g.opts.Module).Check(level, side+" "+fullMethod); ce != nil {
+		if payloads != nil && g.opts.LogPayload {
+			fields = append(fields, payloads()...)
+		}
+		ce.Write(fields...)
+	}
+}
+
+// UnaryServerInterceptor 记录 unary 请求的方法, 调用方, 耗时, 状态码和消息大小.
+func UnaryServerInterceptor(opts GRPCLogOptions) grpc.UnaryServerInterceptor {
+	g := newGRPCLogger(opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if g.skip(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		payloads := func() []zap.Field {
+			return []zap.Field{g.payload("grpc.request", req), g.payload("grpc.response", resp)}
+		}
+		g.log(ctx, "server", info.FullMethod, start, err, payloads,
+			zap.Int("grpc.request_size", messageSize(req)),
+			zap.Int("grpc.response_size", messageSize(resp)),
+		)
+		return resp, err
+	}
+}
+
+// UnaryClientInterceptor 同 UnaryServerInterceptor, 用于客户端.
+func UnaryClientInterceptor(opts GRPCLogOptions) grpc.UnaryClientInterceptor {
+	g := newGRPCLogger(opts)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		if g.skip(method) {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		respSize := 0
+		if err == nil {
+			respSize = messageSize(reply)
+		}
+		payloads := func() []zap.Field {
+			if err != nil {
+				return []zap.Field{g.payload("grpc.request", req)}
+			}
+			return []zap.Field{g.payload("grpc.request", req), g.payload("grpc.response", reply)}
+		}
+		g.log(ctx, "client", method, start, err, payloads,
+			zap.String("grpc.target", cc.Target()),
+			zap.Int("grpc.request_size", messageSize(req)),
+			zap.Int("grpc.response_size", respSize),
+		)
+		return err
+	}
+}
+
+// streamStats 统计 stream 收发的消息数和大小, 并记录第一条消息. SendMsg 和 RecvMsg 可能在不同的协程中调用.
+type streamStats struct {
+	g                  *grpcLogger
+	mu                 sync.Mutex
+	sent, recv         int
+	sentSize, recvSize int
+	firstSent          zap.Field
+	firstRecv          zap.Field
+}
+
+func (s *streamStats) onSend(msg interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sent == 0 {
+		s.firstSent = s.g.payload("grpc.first_sent", msg)
+	}
+	s.sent++
+	s.sentSize += messageSize(msg)
+}
+
+func (s *streamStats) onRecv(msg interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.recv == 0 {
+		s.firstRecv = s.g.payload("grpc.first_recv", msg)
+	}
+	s.recv++
+	s.recvSize += messageSize(msg)
+}
+
+func (s *streamStats) fields() []zap.Field {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := []zap.Field{
+		zap.Int("grpc.sent", s.sent),
+		zap.Int("grpc.sent_size", s.sentSize),
+		zap.Int("grpc.recv", s.recv),
+		zap.Int("grpc.recv_size", s.recvSize),
+	}
+	if s.firstSent.Key != "" {
+		res = append(res, s.firstSent)
+	}
+	if s.firstRecv.Key != "" {
+		res = append(res, s.firstRecv)
+	}
+	return res
+}
+
+type loggedServerStream struct {
+	grpc.ServerStream
+	stats *streamStats
+}
+
+func (s *loggedServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.stats.onSend(m)
+	}
+	return err
+}
+
+func (s *loggedServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.stats.onRecv(m)
+	}
+	return err
+}
+
+// StreamServerInterceptor 在 stream 结束时记录一条日志, 附带收发的消息数和大小.
+func StreamServerInterceptor(opts GRPCLogOptions) grpc.StreamServerInterceptor {
+	g := newGRPCLogger(opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if g.skip(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		start := time.Now()
+		wrapped := &loggedServerStream{ServerStream: ss, stats: &streamStats{g: g}}
+		err := handler(srv, wrapped)
+		g.log(ss.Context(), "server", info.FullMethod, start, err, nil, wrapped.stats.fields()...)
+		return err
+	}
+}
+
+type loggedClientStream struct {
+	grpc.ClientStream
+	g             *grpcLogger
+	ctx           context.Context
+	method        string
+	target        string
+	start         time.Time
+	stats         *streamStats
+	serverStreams bool // 为 false 时服务端只返回一条消息, 收到后即结束
+	once          sync.Once
+	done          chan struct{}
+}
+
+func (s *loggedClientStream) finish(err error) {
+	s.once.Do(func() {
+		close(s.done)
+		s.g.log(s.ctx, "client", s.method, s.start, err, nil, append(s.stats.fields(), zap.String("grpc.target", s.target))...)
+	})
+}
+
+// watch 调用方没有读到结束就放弃 stream(取消 ctx)时, 同样记录日志.
+func (s *loggedClientStream) watch() {
+	if s.ctx.Done() == nil {
+		return
+	}
+	go func() {
+		select {
+		case <-s.ctx.Done():
+			s.finish(status.FromContextError(s.ctx.Err()).Err())
+		case <-s.done:
+		}
+	}()
+}
+
+func (s *loggedClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.stats.onSend(m)
+	}
+	return err
+}
+
+// RecvMsg 收到 io.EOF 或错误时 stream 结束, 记录日志; 服务端不是 stream 时, 收到响应即结束.
+func (s *loggedClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		s.stats.onRecv(m)
+		if !s.serverStreams {
+			s.finish(nil)
+		}
+		return nil
+	}
+	if err == io.EOF {
+		s.finish(nil)
+	} else {
+		s.finish(err)
+	}
+	return err
+}
+
+// StreamClientInterceptor 在 stream 结束(RecvMsg 返回 io.EOF 或错误, 收到 client stream 的响应, 或 ctx 被取消)时记录一条日志.
+func StreamClientInterceptor(opts GRPCLogOptions) grpc.StreamClientInterceptor {
+	g := newGRPCLogger(opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if g.skip(method) {
+			return streamer(ctx, desc, cc, method, callOpts...)
+		}
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, callOpts...)
+		if err != nil {
+			g.log(ctx, "client", method, start, err, nil, zap.String("grpc.target", cc.Target()))
+			return nil, err
+		}
+		ls := &loggedClientStream{
+			ClientStream:  cs,
+			g:             g,
+			ctx:           ctx,
+			method:        method,
+			target:        cc.Target(),
+			start:         start,
+			stats:         &streamStats{g: g},
+			serverStreams: desc.ServerStreams,
+			done:          make(chan struct{}),
+		}
+		ls.watch()
+		return ls, nil
+	}
+}
@@ -0,0 +1,266 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func dialBufconn(t *testing.T, server, client GRPCLogOptions) healthpb.HealthClient {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(server)),
+		grpc.StreamInterceptor(StreamServerInterceptor(server)),
+	)
+	hs := health.NewServer()
+	hs.SetServingStatus("api", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, hs)
+	go s.Serve(lis)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(client)),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(client)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		s.Stop()
+	})
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestGRPCUnaryInterceptor(t *testing.T) {
+	YamlInit([]byte(`logging:
+  grpc_server:
+    handler:
+      - typ: memory
+        level: debug
+  grpc_client:
+    handler:
+      - typ: memory
+        level: debug`))
+	client := dialBufconn(t,
+		GRPCLogOptions{Module: "grpc_server", LogPayload: true, Redact: []RedactRule{{Field: "service", Mode: RedactFull}}},
+		GRPCLogOptions{Module: "grpc_client"},
+	)
+	ctx := WithTraceID(context.Background(), "trace-1")
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "api"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "missing"}); err == nil {
+		t.Fatal("expected NotFound")
+	}
+
+	server := Memory("grpc_server").Entries()
+	if len(server) != 2 {
+		t.Fatalf("server entries=%+v", server)
+	}
+	ok := server[0]
+	if ok.Level != zapcore.InfoLevel || ok.Fields["grpc.method"] != "Check" || ok.Fields["grpc.code"] != "OK" || ok.Fields["peer.address"] == nil {
+		t.Errorf("ok entry=%+v", ok)
+	}
+	if req, _ := ok.Fields["grpc.request"].(map[string]interface{}); req["service"] != "******" {
+		t.Errorf("payload not redacted: %+v", ok.Fields["grpc.request"])
+	}
+	if v, _ := ok.Field("grpc.request_size"); v == "0" {
+		t.Errorf("request_size=%s", v)
+	}
+	if server[1].Level != zapcore.WarnLevel || server[1].Fields["grpc.code"] != "NotFound" {
+		t.Errorf("not found entry=%+v", server[1])
+	}
+
+	clientLog := Memory("grpc_client").Entries()
+	if len(clientLog) != 2 || clientLog[0].Fields["grpc.side"] != "client" || clientLog[0].Fields[FieldTraceID] != "trace-1" {
+		t.Errorf("client entries=%+v", clientLog)
+	}
+	if clientLog[0].Fields["grpc.request"] != nil {
+		t.Error("payload logged without LogPayload")
+	}
+}
+
+func TestGRPCSlowAndSkip(t *testing.T) {
+	YamlInit([]byte(`logging:
+  grpc_slow:
+    handler:
+      - typ: memory
+        level: debug
+  grpc_skip:
+    handler:
+      - typ: memory
+        level: debug`))
+	client := dialBufconn(t,
+		GRPCLogOptions{Module: "grpc_slow", SlowThreshold: time.Nanosecond},
+		GRPCLogOptions{Module: "grpc_skip", SkipHealthCheck: true},
+	)
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "api"}); err != nil {
+		t.Fatal(err)
+	}
+	res := Memory("grpc_slow").Entries()
+	if len(res) != 1 || res[0].Level != zapcore.WarnLevel || res[0].Fields["slow"] != true {
+		t.Errorf("slow entries=%+v", res)
+	}
+	if n := Memory("grpc_skip").Len(); n != 0 {
+		t.Errorf("health check logged: %d", n)
+	}
+}
+
+func TestGRPCPayloadLazy(t *testing.T) {
+	YamlInit([]byte(`logging:
+  grpc_lazy:
+    handler:
+      - typ: memory
+        level: warn`))
+	g := newGRPCLogger(GRPCLogOptions{Module: "grpc_lazy", LogPayload: true})
+	called := 0
+	payloads := func() []zap.Field {
+		called++
+		return []zap.Field{g.payload("grpc.request", wrapperspb.String("x"))}
+	}
+	g.log(context.Background(), "server", "/test.Test/Call", time.Now(), nil, payloads)
+	if called != 0 || Memory("grpc_lazy").Len() != 0 {
+		t.Errorf("payload built for disabled level: called=%d", called)
+	}
+	g.log(context.Background(), "server", "/test.Test/Call", time.Now(), status.Error(codes.Internal, "x"), payloads)
+	if ent := Memory("grpc_lazy").Entries(); called != 1 || len(ent) != 1 || ent[0].Fields["grpc.request"] != "x" {
+		t.Errorf("called=%d, entries=%+v", called, ent)
+	}
+}
+
+func TestGRPCStreamInterceptor(t *testing.T) {
+	YamlInit([]byte(`logging:
+  grpc_stream:
+    handler:
+      - typ: memory
+        level: debug`))
+	client := dialBufconn(t, GRPCLogOptions{Module: "grpc_stream"}, GRPCLogOptions{Module: "grpc_stream"})
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if _, err := stream.Recv(); err == nil {
+		t.Fatal("expected canceled")
+	}
+	m := Memory("grpc_stream")
+	deadline := time.Now().Add(2 * time.Second)
+	for m.Len() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	server := m.ByField("grpc.side", "server")
+	client2 := m.ByField("grpc.side", "client")
+	if len(server) != 1 || len(client2) != 1 {
+		t.Fatalf("entries=%+v", m.Entries())
+	}
+	if v, _ := server[0].Field("grpc.sent"); v != "1" {
+		t.Errorf("server sent=%s", v)
+	}
+	if v, _ := client2[0].Field("grpc.recv"); v != "1" || client2[0].Fields["grpc.code"] != "Canceled" {
+		t.Errorf("client entry=%+v", client2[0])
+	}
+}
+
+// dialUpload 启动只有 client stream 方法 /test.Upload/Count 的服务, 返回收到的消息数.
+func dialUpload(t *testing.T, client GRPCLogOptions) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.Upload",
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{{StreamName: "Count", ClientStreams: true, Handler: func(srv interface{}, ss grpc.ServerStream) error {
+			n := 0
+			for {
+				if err := ss.RecvMsg(&wrapperspb.StringValue{}); err == io.EOF {
+					break
+				} else if err != nil {
+					return err
+				}
+				n++
+			}
+			return ss.SendMsg(wrapperspb.String(strconv.Itoa(n)))
+		}}},
+	}, struct{}{})
+	go s.Serve(lis)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(client)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		s.Stop()
+	})
+	return conn
+}
+
+func TestGRPCClientStreamInterceptor(t *testing.T) {
+	YamlInit([]byte(`logging:
+  grpc_upload:
+    handler:
+      - typ: memory
+        level: debug`))
+	conn := dialUpload(t, GRPCLogOptions{Module: "grpc_upload"})
+	desc := &grpc.StreamDesc{ClientStreams: true}
+	stream, err := conn.NewStream(context.Background(), desc, "/test.Upload/Count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := stream.SendMsg(wrapperspb.String("x")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream.CloseSend()
+	resp := &wrapperspb.StringValue{}
+	if err := stream.RecvMsg(resp); err != nil || resp.Value != "3" {
+		t.Fatalf("resp=%v, err=%v", resp, err)
+	}
+	m := Memory("grpc_upload")
+	if m.Len() != 1 {
+		t.Fatalf("entries=%+v", m.Entries())
+	}
+	ent := m.Entries()[0]
+	if sent, _ := ent.Field("grpc.sent"); sent != "3" || ent.Fields["grpc.code"] != "OK" {
+		t.Errorf("entry=%+v", ent)
+	}
+
+	// 调用方取消 ctx 放弃 stream, 不再调用 RecvMsg.
+	m.Reset()
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err = conn.NewStream(ctx, desc, "/test.Upload/Count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.SendMsg(wrapperspb.String("x"))
+	cancel()
+	deadline := time.Now().Add(2 * time.Second)
+	for m.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if res := m.Entries(); len(res) != 1 || res[0].Fields["grpc.code"] != "Canceled" {
+		t.Errorf("abandoned entries=%+v", res)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"syscall"
 	"time"
 )
 
@@ -24,25 +25,93 @@ type Handler interface {
 	GetFormat() zapcore.Encoder
 }
 
+// closeWriter 关闭 handler 创建的 writer: 刷出缓冲区, 停止后台协程(切割, 外部切割检测, 缓冲刷出), 关闭文件和连接.
+// 标准输出和标准错误不关闭.
+func closeWriter(w io.Writer) error {
+	switch v := w.(type) {
+	case *BufferedWriter:
+		err := v.Close()
+		unregisterBuffered(v)
+		if cerr := closeWriter(v.out); err == nil {
+			err = cerr
+		}
+		return err
+	case *os.File:
+		if v == os.Stdout || v == os.Stderr {
+			return nil
+		}
+	}
+	if c, ok := w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// CoreWrapper 可选接口, Build 时用于包装 handler 对应的 core, 如采样, 过滤等.
+type CoreWrapper interface {
+	WrapCore(core zapcore.Core) zapcore.Core
+}
+
+// wrappedHandler 附加了通用配置(采样等)的 handler.
+type wrappedHandler struct {
+	Handler
+	wraps []func(zapcore.Core) zapcore.Core
+}
+
+func (h *wrappedHandler) WrapCore(core zapcore.Core) zapcore.Core {
+	if w, ok := h.Handler.(CoreWrapper); ok {
+		core = w.WrapCore(core)
+	}
+	for _, wrap := range h.wraps {
+		core = wrap(core)
+	}
+	return core
+}
+
+func (h *wrappedHandler) String() string {
+	return fmt.Sprintf("%v", h.Handler)
+}
+
 type FileHandler struct {
-	Filename string
-	Level    zapcore.Level
-	Format   zapcore.Encoder
+	Filename    string
+	Level       zapcore.Level
+	Format      zapcore.Encoder
+	Buffer      *BufferOption // 非空时异步写入
+	Reopen      bool          // 支持外部切割后重新打开文件
+	ReopenCheck time.Duration // 检测外部切割的间隔, 0 表示只在信号或 logger.Reopen() 时重新打开
 }
 
 func (h *FileHandler) BuildWriter() (io.Writer, error) {
+	var writer io.Writer
 	switch h.Filename {
 	case "/dev/stdout":
-		return os.Stdout, nil
+		writer = os.Stdout
 	case "/dev/stderr":
-		return os.Stderr, nil
+		writer = os.Stderr
 	case os.DevNull:
 		return ioutil.Discard, nil
 	case "":
 		return nil, errors.New("empty filename")
 	default:
-		return os.OpenFile(h.Filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+		if h.Reopen {
+			fp, err := OpenReopenFile(h.Filename)
+			if err != nil {
+				return nil, err
+			}
+			fp.unregister = registerReopener(fp, h.ReopenCheck)
+			writer = fp
+			break
+		}
+		fp, err := os.OpenFile(h.Filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		writer = fp
+	}
+	if h.Buffer != nil {
+		return registerBuffered(h.Filename, NewBufferedWriter(writer, *h.Buffer)), nil
 	}
+	return writer, nil
 }
 
 func (h *FileHandler) GetLevel() zapcore.Level {
@@ -54,9 +123,18 @@ func (h *FileHandler) GetFormat() zapcore.Encoder {
 }
 
 type EmailHandler struct {
-	Level   zapcore.Level
-	Format  zapcore.Encoder
-	Subject string
+	Level       zapcore.Level
+	Format      zapcore.Encoder
+	Subject     string
+	MinInterval time.Duration // 相同内容的日志, 在该时间内只发送一封邮件
+	Module      string
+}
+
+func (h *EmailHandler) WrapCore(core zapcore.Core) zapcore.Core {
+	if h.MinInterval <= 0 {
+		return core
+	}
+	return newIntervalCore(core, h.Module, h.MinInterval)
 }
 
 func (h *EmailHandler) Write(p []byte) (int, error) {
@@ -66,7 +144,13 @@ func (h *EmailHandler) Write(p []byte) (int, error) {
 			subject = h.Subject
 		}
 		err := email.SendServerMail(subject, body, "text/plain")
+		observeEmail(h.Module, err)
 		if err != nil {
+			// 重新初始化后 app 可能已经不存在.
+			if !hasModule("app") {
+				fmt.Println(time.Now().Format(time.RFC3339), syscall.Getpid(), "[email handler]", err)
+				return
+			}
 			SugarL("app").Warnw("日志邮件发送模块异常", "thing", "error", "err", err, "mail_body", string(p))
 		}
 	}(string(p))
@@ -86,12 +170,20 @@ func (h *EmailHandler) GetFormat() zapcore.Encoder {
 }
 
 type RotateHandler struct {
-	Filename string
-	Layout   string
-	Duration time.Duration
-	Replica  int
-	Level    zapcore.Level
-	Format   zapcore.Encoder
+	Filename    string
+	Layout      string
+	Duration    time.Duration
+	Replica     int
+	Level       zapcore.Level
+	Format      zapcore.Encoder
+	Buffer      *BufferOption   // 非空时异步写入
+	Reopen      bool            // 支持外部切割后重新打开文件
+	ReopenCheck time.Duration   // 检测外部切割的间隔, 0 表示只在信号或 logger.Reopen() 时重新打开
+	Shared      bool            // 多个进程写同一个文件, 通过文件锁选出一个进程切割, 其他进程重新打开
+	Hooks       []RotateHook    // 切割完成后在后台执行, 见 RotateHook
+	HookRetry   int             // hook 失败后的重试次数
+	HookBackoff time.Duration   // 第一次重试的间隔, 之后每次翻倍, 默认 1s
+	Retention   RetentionPolicy // 历史文件的保留策略, MaxCount 为 0 时使用 Replica
 }
 
 func (h *RotateHandler) BuildWriter() (io.Writer, error) {
@@ -99,7 +191,28 @@ func (h *RotateHandler) BuildWriter() (io.Writer, error) {
 		return nil, errors.New("invalid filename: " + h.Filename)
 	}
 	fmt.Println("NOTICE: 查看是否有同一个文件被初始化两次", h.Filename, h.Duration)
-	return RotateWriter(h.Filename, h.Layout, h.Duration, h.Replica)
+	hooks := newHookRunner(h.Hooks, h.HookRetry, h.HookBackoff)
+	policy := h.Retention
+	if policy.MaxCount == 0 {
+		policy.MaxCount = h.Replica
+	}
+	w, err := rotateWriter(&RotateFile{
+		filename:  h.Filename,
+		replica:   h.Replica,
+		shared:    h.Shared,
+		hooks:     hooks,
+		retention: &Retention{Filename: h.Filename, Policy: policy, hooks: hooks},
+	}, h.Layout, h.Duration)
+	if err != nil {
+		return nil, err
+	}
+	if h.Reopen {
+		w.unregister = registerReopener(w, h.ReopenCheck)
+	}
+	if h.Buffer != nil {
+		return registerBuffered(h.Filename, NewBufferedWriter(w, *h.Buffer)), nil
+	}
+	return w, nil
 }
 
 func (h *RotateHandler) GetLevel() zapcore.Level {
@@ -0,0 +1,332 @@
+package logger
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 21:10
+// @File   : hooks.go
+// @Project: utils/logger
+// ==========================
+
+// RotatedFile 切割完成的历史文件.
+type RotatedFile struct {
+	Path     string    // 历史文件路径, 如 /tmp/access.log.2026-10-19
+	Filename string    // 当前写入的文件, 如 /tmp/access.log
+	Start    time.Time // 该文件对应的周期 [Start, End)
+	End      time.Time
+}
+
+// RotateHook 切割完成后对历史文件的处理, 如归档, 上传, 计算校验和.
+// 在后台按配置顺序执行, 失败时重试; 全部成功后写入完成标记(见 hookDonePath), 没有标记的文件不会被清理删除.
+type RotateHook interface {
+	AfterRotate(file RotatedFile) error
+}
+
+// RotateHookFunc 函数形式的 RotateHook.
+type RotateHookFunc func(file RotatedFile) error
+
+func (f RotateHookFunc) AfterRotate(file RotatedFile) error {
+	return f(file)
+}
+
+// RotateHookFactory 根据 rotate_file.hooks 中的原始配置创建 hook.
+type RotateHookFactory func(node *RawNode) (RotateHook, error)
+
+var (
+	rotateHookTypesMu sync.RWMutex
+	rotateHookTypes   = map[string]RotateHookFactory{}
+)
+
+// RegisterRotateHookType 注册 hook 类型, 名称即 hooks 中的 typ, 重复注册会 panic.
+func RegisterRotateHookType(name string, factory RotateHookFactory) {
+	rotateHookTypesMu.Lock()
+	defer rotateHookTypesMu.Unlock()
+	if _, ok := rotateHookTypes[name]; ok {
+		panic("rotate hook typ " + name + " 重复注册")
+	}
+	rotateHookTypes[name] = factory
+}
+
+// RotateHookTypes 返回已注册的 hook 类型.
+func RotateHookTypes() []string {
+	rotateHookTypesMu.RLock()
+	defer rotateHookTypesMu.RUnlock()
+	res := make([]string, 0, len(rotateHookTypes))
+	for name := range rotateHookTypes {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// buildRotateHooks 解析 rotate_file.hooks.
+func buildRotateHooks(module string, nodes []yaml.MapSlice) ([]RotateHook, error) {
+	hooks := make([]RotateHook, 0, len(nodes))
+	for i, node := range nodes {
+		v, _ := mapGet(node, "typ")
+		typ, _ := v.(string)
+		rotateHookTypesMu.RLock()
+		factory, ok := rotateHookTypes[typ]
+		rotateHookTypesMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("invalid hook typ: %q (hooks[%d]), support: %s", typ, i, strings.Join(RotateHookTypes(), ", "))
+		}
+		hook, err := factory(&RawNode{Module: module, Index: i, Typ: typ, node: node})
+		if err != nil {
+			return nil, fmt.Errorf("hooks[%d](%s): %v", i, typ, err)
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, nil
+}
+
+// hookRunner 在后台执行 hook, 并记录各历史文件的状态.
+type hookRunner struct {
+	hooks   []RotateHook
+	retry   int           // 失败后的重试次数
+	backoff time.Duration // 第一次重试的间隔, 之后每次翻倍
+
+	mu      sync.Mutex
+	pending map[string]bool // path -> 是否已过期(超出 replica), 过期的文件在 hook 成功后删除
+	wg      sync.WaitGroup
+}
+
+func newHookRunner(hooks []RotateHook, retry int, backoff time.Duration) *hookRunner {
+	if len(hooks) == 0 {
+		return nil
+	}
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	return &hookRunner{hooks: hooks, retry: retry, backoff: backoff, pending: map[string]bool{}}
+}
+
+// hookDonePath 返回历史文件的 hook 完成标记, 与历史文件在同一目录, 如 /tmp/.access.log.2026-10-19.hooked.
+// 标记保存在磁盘上, 重启之后以及 shared 模式下的其他进程清理时, 同样只删除 hook 已完成的文件.
+func hookDonePath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".hooked")
+}
+
+// hooked 返回文件的 hook 是否已全部成功.
+func hooked(path string) bool {
+	_, err := os.Stat(hookDonePath(path))
+	return err == nil
+}
+
+func (r *hookRunner) dispatch(file RotatedFile) {
+	r.mu.Lock()
+	if _, ok := r.pending[file.Path]; ok {
+		r.mu.Unlock()
+		return
+	}
+	r.pending[file.Path] = false
+	r.mu.Unlock()
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		err := r.run(file)
+		if err == nil {
+			err = os.WriteFile(hookDonePath(file.Path), nil, 0644)
+		}
+		r.mu.Lock()
+		expired := r.pending[file.Path]
+		if err == nil {
+			delete(r.pending, file.Path)
+		}
+		r.mu.Unlock()
+		if err != nil {
+			// 保留文件, 不再删除, 需要人工处理.
+			fmt.Fprintf(os.Stderr, "%s [rotate hook] file=%s, err=%v\n", time.Now().Format(time.RFC3339), file.Path, err)
+			return
+		}
+		if expired {
+			removeHistory(file.Path)
+		}
+	}()
+}
+
+func (r *hookRunner) run(file RotatedFile) error {
+	for i, hook := range r.hooks {
+		backoff := r.backoff
+		var err error
+		for attempt := 0; attempt <= r.retry; attempt++ {
+			if attempt > 0 {
+				time.Sleep(backoff)
+				backoff *= 2
+			}
+			if err = hook.AfterRotate(file); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("hooks[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// release 文件超出保留数量时调用, 返回是否可以立即删除. hook 正在执行时, 在完成后删除;
+// 没有完成标记的文件(执行失败, 重启前没有完成, 或 shared 模式下由其他进程执行)保留.
+func (r *hookRunner) release(path string) bool {
+	if r == nil {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.pending[path]; ok {
+		r.pending[path] = true
+		return false
+	}
+	return hooked(path)
+}
+
+// resume 对没有完成标记的历史文件重新执行 hook, 在启动时调用.
+func (r *hookRunner) resume(filename string, files []HistoryFile, duration time.Duration) {
+	if r == nil {
+		return
+	}
+	for _, f := range files {
+		if !hooked(f.Path) {
+			r.dispatch(RotatedFile{Path: f.Path, Filename: filename, Start: f.Time, End: f.Time.Add(duration)})
+		}
+	}
+}
+
+// removeHistory 删除历史文件及其 hook 完成标记.
+func removeHistory(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	os.Remove(hookDonePath(path))
+	return nil
+}
+
+// Wait 等待正在执行的 hook 完成, 用于测试和退出前.
+func (r *hookRunner) Wait() {
+	if r != nil {
+		r.wg.Wait()
+	}
+}
+
+// ArchiveHook 把历史文件复制到 Dir, Gzip 为 true 时压缩为 .gz.
+type ArchiveHook struct {
+	Dir  string `yaml:"dir"`
+	Gzip bool   `yaml:"gzip"`
+}
+
+func (h *ArchiveHook) AfterRotate(file RotatedFile) (err error) {
+	if err := os.MkdirAll(h.Dir, 0755); err != nil {
+		return err
+	}
+	src, err := os.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	target := filepath.Join(h.Dir, filepath.Base(file.Path))
+	if h.Gzip {
+		target += ".gz"
+	}
+	// 先写临时文件, 完成后改名, 避免留下不完整的归档.
+	tmp := target + ".tmp"
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp)
+		}
+	}()
+	var w io.Writer = dst
+	var gz *gzip.Writer
+	if h.Gzip {
+		gz = gzip.NewWriter(dst)
+		gz.Name = filepath.Base(file.Path)
+		w = gz
+	}
+	if _, err = io.Copy(w, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if gz != nil {
+		if err = gz.Close(); err != nil {
+			dst.Close()
+			return err
+		}
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, target)
+}
+
+// ChecksumHook 把历史文件的 sha256 追加到 Manifest(sha256sum 格式), 默认为同目录下的 SHA256SUMS.
+type ChecksumHook struct {
+	Manifest string `yaml:"manifest"`
+
+	mu sync.Mutex
+}
+
+func (h *ChecksumHook) AfterRotate(file RotatedFile) error {
+	f, err := os.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sum := sha256.New()
+	if _, err := io.Copy(sum, f); err != nil {
+		return err
+	}
+	manifest := h.Manifest
+	if manifest == "" {
+		manifest = filepath.Join(filepath.Dir(file.Path), "SHA256SUMS")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out, err := os.OpenFile(manifest, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(out, "%s  %s\n", hex.EncodeToString(sum.Sum(nil)), filepath.Base(file.Path)); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func init() {
+	RegisterRotateHookType("archive", func(node *RawNode) (RotateHook, error) {
+		h := &ArchiveHook{}
+		if err := node.Decode(h); err != nil {
+			return nil, err
+		}
+		if h.Dir == "" {
+			return nil, errors.New("archive dir required")
+		}
+		return h, nil
+	})
+	RegisterRotateHookType("checksum", func(node *RawNode) (RotateHook, error) {
+		h := &ChecksumHook{}
+		if err := node.Decode(h); err != nil {
+			return nil, err
+		}
+		return h, nil
+	})
+}
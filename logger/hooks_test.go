@@ -0,0 +1,238 @@
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestArchiveAndChecksumHook(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log.2026-10-19")
+	if err := os.WriteFile(path, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file := RotatedFile{Path: path, Filename: filepath.Join(dir, "access.log")}
+	archive := &ArchiveHook{Dir: filepath.Join(dir, "archive"), Gzip: true}
+	if err := archive.AfterRotate(file); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filepath.Join(dir, "archive", "access.log.2026-10-19.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(gz); string(data) != "hello\n" {
+		t.Errorf("archive content=%q", data)
+	}
+
+	if err := (&ChecksumHook{}).AfterRotate(file); err != nil {
+		t.Fatal(err)
+	}
+	manifest, _ := os.ReadFile(filepath.Join(dir, "SHA256SUMS"))
+	want := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  access.log.2026-10-19\n"
+	if string(manifest) != want {
+		t.Errorf("manifest=%q, want %q", manifest, want)
+	}
+}
+
+func TestRotateHookRetention(t *testing.T) {
+	dir := t.TempDir()
+	var calls int32
+	release := make(chan struct{})
+	hook := RotateHookFunc(func(file RotatedFile) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return errors.New("upload failed")
+		}
+		<-release
+		return nil
+	})
+	r := newHookRunner([]RotateHook{hook}, 1, time.Millisecond)
+	w := &RotateFile{filename: filepath.Join(dir, "access.log"), hooks: r, retention: &Retention{hooks: r}}
+	old := filepath.Join(dir, "access.log.old")
+	os.WriteFile(old, []byte("x"), 0644)
+	w.lastRotate = time.Now()
+	w.afterRotate(old, time.Hour)
+
+	// hook 未完成, 超出 replica 时不删除.
+	a := RetentionAction{File: HistoryFile{Path: old}}
+	w.retention.remove(&a, false)
+	if _, err := os.Stat(old); err != nil || !a.Deferred {
+		t.Fatalf("file removed before hook finished: %v", err)
+	}
+	close(release)
+	r.Wait()
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("file should be removed after hook succeeded: %v", err)
+	}
+	if _, err := os.Stat(hookDonePath(old)); !os.IsNotExist(err) {
+		t.Errorf("marker should be removed with the file: %v", err)
+	}
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Errorf("calls=%v, want 2(retry once)", calls)
+	}
+
+	// 不在当前进程中执行的文件(如重启前切割, 或由其他进程执行)按完成标记判断.
+	other := filepath.Join(dir, "access.log.other")
+	os.WriteFile(other, []byte("x"), 0644)
+	a = RetentionAction{File: HistoryFile{Path: other}}
+	w.retention.remove(&a, false)
+	if _, err := os.Stat(other); err != nil || !a.Deferred {
+		t.Errorf("file without marker should be kept: %v", err)
+	}
+	os.WriteFile(hookDonePath(other), nil, 0644)
+	w.retention.remove(&RetentionAction{File: HistoryFile{Path: other}}, false)
+	if _, err := os.Stat(other); !os.IsNotExist(err) {
+		t.Errorf("file with marker should be removed: %v", err)
+	}
+}
+
+func TestRotateHookResume(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "access.log")
+	layout := "2006-01-02T15"
+	base := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	var paths []string
+	for i := 0; i < 3; i++ {
+		path := filename + "." + base.Add(time.Duration(i)*time.Hour).Format(layout)
+		os.WriteFile(path, []byte("x"), 0644)
+		paths = append(paths, path)
+	}
+	// 重启前第一个文件的 hook 已完成, 其余的没有完成.
+	os.WriteFile(hookDonePath(paths[0]), nil, 0644)
+
+	var mu sync.Mutex
+	var got []string
+	release := make(chan struct{})
+	r := newHookRunner([]RotateHook{RotateHookFunc(func(file RotatedFile) error {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, file.Path)
+		return nil
+	})}, 0, time.Millisecond)
+	w := &RotateFile{filename: filename, hooks: r}
+	w.retention = &Retention{Filename: filename, Policy: RetentionPolicy{MaxCount: 1}, hooks: r}
+	if err := w.Reset(layout, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	defer w.fp.Close()
+	// 完成的文件直接删除, 未完成的文件在 hook 重新执行完成后删除.
+	if _, err := os.Stat(paths[0]); !os.IsNotExist(err) {
+		t.Errorf("hooked file should be removed: %v", err)
+	}
+	if _, err := os.Stat(paths[1]); err != nil {
+		t.Errorf("file should be kept until hook finished: %v", err)
+	}
+	close(release)
+	r.Wait()
+	sort.Strings(got)
+	if strings.Join(got, ",") != strings.Join(paths[1:], ",") {
+		t.Errorf("resumed=%v", got)
+	}
+	if _, err := os.Stat(paths[1]); !os.IsNotExist(err) {
+		t.Errorf("file should be removed after hook resumed: %v", err)
+	}
+	if _, err := os.Stat(paths[2]); err != nil || !hooked(paths[2]) {
+		t.Errorf("kept file should be marked: %v", err)
+	}
+}
+
+func TestRotateHookFailed(t *testing.T) {
+	dir := t.TempDir()
+	r := newHookRunner([]RotateHook{RotateHookFunc(func(RotatedFile) error { return errors.New("down") })}, 2, time.Millisecond)
+	w := &RotateFile{filename: filepath.Join(dir, "access.log"), hooks: r, retention: &Retention{hooks: r}}
+	old := filepath.Join(dir, "access.log.old")
+	os.WriteFile(old, []byte("x"), 0644)
+	w.afterRotate(old, time.Hour)
+	r.Wait()
+	w.retention.remove(&RetentionAction{File: HistoryFile{Path: old}}, false)
+	if _, err := os.Stat(old); err != nil {
+		t.Errorf("file with failed hook should be kept: %v", err)
+	}
+}
+
+func TestRotateHookConfig(t *testing.T) {
+	dir := t.TempDir()
+	var got RotatedFile
+	t.Cleanup(func() {
+		rotateHookTypesMu.Lock()
+		delete(rotateHookTypes, "test_upload")
+		rotateHookTypesMu.Unlock()
+	})
+	RegisterRotateHookType("test_upload", func(node *RawNode) (RotateHook, error) {
+		var cfg struct {
+			Bucket string `yaml:"bucket"`
+		}
+		if err := node.Decode(&cfg); err != nil || cfg.Bucket != "logs" {
+			return nil, errors.New("bucket required")
+		}
+		return RotateHookFunc(func(file RotatedFile) error {
+			got = file
+			return nil
+		}), nil
+	})
+	cfg, err := ParseConfig([]byte(`logging:
+  hooks:
+    handler:
+      - typ: rotate_file
+        filename: "` + filepath.Join(dir, "access.log") + `"
+        duration: 1h
+        level: info
+        hooks:
+          - typ: test_upload
+            bucket: logs
+          - typ: checksum
+        hook_backoff: 10ms`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := buildHandler("hooks", 0, &cfg.Logging["hooks"].Handler[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	rh := h.(*RotateHandler)
+	if len(rh.Hooks) != 2 || rh.HookRetry != 3 || rh.HookBackoff != 10*time.Millisecond {
+		t.Fatalf("handler=%+v", rh)
+	}
+	w, err := rotateWriter(&RotateFile{filename: rh.Filename, replica: 3, hooks: newHookRunner(rh.Hooks, rh.HookRetry, rh.HookBackoff)}, rh.Layout, rh.Duration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("line\n"))
+	if err := w.Rotate(rh.Layout, rh.Duration); err != nil {
+		t.Fatal(err)
+	}
+	w.hooks.Wait()
+	if !strings.HasPrefix(got.Path, rh.Filename+".") || got.End.Sub(got.Start) != time.Hour {
+		t.Errorf("hook got %+v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "SHA256SUMS")); err != nil {
+		t.Error(err)
+	}
+
+	cfg, _ = ParseConfig([]byte(`logging:
+  hooks:
+    handler:
+      - typ: rotate_file
+        filename: "/tmp/x.log"
+        duration: 1h
+        level: info
+        hooks:
+          - typ: unknown`))
+	if _, err := buildHandler("hooks", 0, &cfg.Logging["hooks"].Handler[0]); err == nil || !strings.Contains(err.Error(), "invalid hook typ") {
+		t.Errorf("err=%v", err)
+	}
+}
@@ -1,6 +1,9 @@
 package logger
 
 import (
+	"io"
+	"sync"
+
 	"go.uber.org/zap"
 )
 
@@ -11,33 +14,66 @@ import (
 // @Project: aotu/logger
 // ==========================
 
-var loggerMap = map[string]*zap.Logger{}
+var (
+	// loggerMu 保护 loggerMap, loggerSugarMap 和 Logging; 中间件对每个请求都会调用 L, 需要支持并发读.
+	loggerMu       sync.RWMutex
+	loggerMap      = map[string]*zap.Logger{}
+	loggerSugarMap = map[string]*zap.SugaredLogger{}
+	loggerWriters  = map[string][]io.Writer{} // 各 module 的 handler 创建的 writer, 重新初始化时关闭
+	loggerGen      uint64                     // YamlInit 的次数, 用于 lazyLogger 判断缓存是否失效
+)
 
-// 考虑并发读写 map 的问题
 func L(module string) *zap.Logger {
+	loggerMu.RLock()
 	logger, ok := loggerMap[module]
+	loggerMu.RUnlock()
 	if ok {
 		return logger
 	}
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	return buildLogger(module)
+}
+
+// buildLogger 在持有 loggerMu 时调用, 返回缓存的 logger, 不存在时创建.
+func buildLogger(module string) *zap.Logger {
+	if logger, ok := loggerMap[module]; ok {
+		return logger
+	}
 	config, ok := Logging[module]
 	if ok {
-		logger := config.Build()
+		if config.Module == "" {
+			config.Module = module
+		}
+		logger, writers := config.build()
 		loggerMap[module] = logger
+		loggerWriters[module] = writers
 		return logger
 	}
 	panic("invalid module: " + module)
 }
 
-var loggerSugarMap = map[string]*zap.SugaredLogger{}
+// hasModule 返回当前配置中是否存在 module.
+func hasModule(module string) bool {
+	loggerMu.RLock()
+	defer loggerMu.RUnlock()
+	_, ok := Logging[module]
+	return ok
+}
 
-// 考虑并发读写 map 的问题
 func SugarL(module string) *zap.SugaredLogger {
+	loggerMu.RLock()
 	logger, ok := loggerSugarMap[module]
+	loggerMu.RUnlock()
 	if ok {
 		return logger
 	}
-	llogger := L(module)
-	logger = llogger.Sugar()
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	if logger, ok := loggerSugarMap[module]; ok {
+		return logger
+	}
+	logger = buildLogger(module).Sugar()
 	loggerSugarMap[module] = logger
 	return logger
 }
@@ -3,7 +3,10 @@ package logger
 import (
 	"encoding/json"
 	"github.com/zero-miao/go-utils/email"
+	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -78,20 +81,94 @@ func BenchmarkSugarLObject(b *testing.B) {
 	}
 }
 
-func TestDeque_Add(t *testing.T) {
-	var d Deque
-	cap_ := 10
-	d.Reset(cap_)
-	for i := 0; i < 21; i++ {
-		temp := d.Add(strconv.Itoa(i))
-		if i < cap_ {
-			if temp != "" {
-				t.Errorf("error return1: %v, want %v", temp, "")
-			}
-		} else {
-			if temp != strconv.Itoa(i-cap_) {
-				t.Errorf("error return2: %v, want %v", temp, i-cap_)
+func TestLConcurrent(t *testing.T) {
+	data := []byte(`logging:
+  concurrent:
+    handler:
+      - typ: memory
+        level: debug`)
+	YamlInit(data)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				L("concurrent").Debug("l")
+				SugarL("concurrent").Debug("sugar")
 			}
-		}
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		YamlInit(data)
+	}
+	wg.Wait()
+}
+
+func TestYamlInitClosesHandlers(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte(`logging:
+  reinit:
+    handler:
+      - typ: rotate_file
+        filename: ` + dir + `/reinit.log
+        duration: 24h
+        level: debug
+        reopen: true
+        reopen_check: 10ms
+      - typ: file
+        filename: ` + dir + `/buffered.log
+        level: debug
+        buffer:
+          size: 8
+  reinit_gone:
+    handler:
+      - typ: memory
+        level: debug`)
+	YamlInit(data)
+	loggerMu.RLock()
+	old := loggerWriters["reinit"]
+	loggerMu.RUnlock()
+	reopenMu.Lock()
+	registered := len(reopeners)
+	reopenMu.Unlock()
+	L("reinit").Info("before")
+
+	YamlInit([]byte(`logging:
+  reinit:
+    handler:
+      - typ: rotate_file
+        filename: ` + dir + `/reinit.log
+        duration: 24h
+        level: debug
+        reopen: true
+        reopen_check: 10ms`))
+	rotate := old[0].(*RotateFile)
+	if !rotate.closed || retentions[dir+"/reinit.log"] == rotate.retention {
+		t.Errorf("old rotate writer not closed: closed=%v", rotate.closed)
+	}
+	if buffered := old[1].(*BufferedWriter); !buffered.closed {
+		t.Error("old buffered writer not closed")
+	}
+	if data, _ := os.ReadFile(dir + "/buffered.log"); !strings.Contains(string(data), "before") {
+		t.Errorf("buffer not flushed on close: %q", data)
+	}
+	reopenMu.Lock()
+	if len(reopeners) != registered {
+		t.Errorf("reopeners=%d, want %d", len(reopeners), registered)
+	}
+	reopenMu.Unlock()
+	if hasModule("reinit_gone") {
+		t.Error("module missing from the new config still exists")
+	}
+	loggerMu.RLock()
+	_, cached := loggerMap["reinit_gone"]
+	loggerMu.RUnlock()
+	if cached {
+		t.Error("logger of removed module still cached")
+	}
+	L("reinit").Info("after")
+	if data, _ := os.ReadFile(dir + "/reinit.log"); strings.Count(string(data), "before")+strings.Count(string(data), "after") != 2 {
+		t.Errorf("reinit.log=%q", data)
 	}
 }
@@ -0,0 +1,220 @@
+package logq
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zero-miao/go-utils/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func writeLog(t *testing.T, path string, enc zapcore.Encoder, gz bool, entries ...zapcore.Entry) {
+	var sb strings.Builder
+	for i, ent := range entries {
+		buf, err := enc.EncodeEntry(ent, []zapcore.Field{zap.Int("i", i), zap.String("day", ent.Time.Format("02"))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sb.WriteString(buf.String())
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	fp, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	if gz {
+		w := gzip.NewWriter(fp)
+		defer w.Close()
+		w.Write([]byte(sb.String()))
+		return
+	}
+	fp.WriteString(sb.String())
+}
+
+func at(day, hour int) time.Time {
+	return time.Date(2024, 1, day, hour, 0, 0, 0, time.UTC)
+}
+
+func TestQuery(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := logger.ParseConfig([]byte(`logging:
+  app:
+    handler:
+      - typ: rotate_file
+        filename: ` + dir + `/app.log
+        duration: 24h
+        hooks:
+          - typ: archive
+            dir: ` + dir + `/archive
+            gzip: true
+  audit:
+    handler:
+      - typ: file
+        name: audit
+        format: json
+        filename: ` + dir + `/audit.log`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources, err := Sources(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 2 || sources[0].Layout != "2006-01-02" || sources[1].Handler != "audit" {
+		t.Fatalf("sources=%+v", sources)
+	}
+
+	console := logger.ConsoleFormatter
+	writeLog(t, dir+"/archive/app.log.2024-01-01.gz", console, true,
+		zapcore.Entry{Time: at(1, 1), Level: zapcore.InfoLevel, Message: "day1"},
+		zapcore.Entry{Time: at(1, 2), Level: zapcore.ErrorLevel, Message: "fail", Stack: "main.go:1\nmain.go:2"},
+	)
+	writeLog(t, dir+"/app.log.2024-01-02", console, false,
+		zapcore.Entry{Time: at(2, 1), Level: zapcore.DebugLevel, Message: "day2", Caller: zapcore.NewEntryCaller(0, "/x/svc/a.go", 10, true)},
+	)
+	writeLog(t, dir+"/app.log", console, false,
+		zapcore.Entry{Time: at(3, 1), Level: zapcore.WarnLevel, Message: "day3\twith tab"},
+	)
+	writeLog(t, dir+"/audit.log", logger.JsonFormatter, false,
+		zapcore.Entry{Time: at(1, 3), Level: zapcore.InfoLevel, Message: "audit1"},
+		zapcore.Entry{Time: at(2, 3), Level: zapcore.WarnLevel, Message: "audit2"},
+	)
+
+	collect := func(q Query) []Entry {
+		res := make([]Entry, 0)
+		if err := q.Run(sources, func(ent Entry) error {
+			res = append(res, ent)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	messages := func(entries []Entry) string {
+		res := make([]string, 0, len(entries))
+		for _, ent := range entries {
+			res = append(res, ent.Message)
+		}
+		return strings.Join(res, ",")
+	}
+
+	all := collect(Query{})
+	if got := messages(all); got != "day1,fail,audit1,day2,audit2,day3\twith tab" {
+		t.Fatalf("all=%q", got)
+	}
+	if all[1].Stack != "main.go:1\nmain.go:2" || all[1].Level != zapcore.ErrorLevel || all[1].Module != "app" {
+		t.Errorf("stack entry=%+v", all[1])
+	}
+	if all[3].Caller != "svc/a.go:10" || all[3].Fields["day"] != "02" {
+		t.Errorf("caller entry=%+v", all[3])
+	}
+	if all[2].Module != "audit" || all[2].Fields["i"] == nil {
+		t.Errorf("json entry=%+v", all[2])
+	}
+
+	if got := messages(collect(Query{Level: zapcore.WarnLevel})); got != "fail,audit2,day3\twith tab" {
+		t.Errorf("level=%q", got)
+	}
+	if got := messages(collect(Query{Since: at(2, 0), Until: at(3, 0), Modules: []string{"app"}})); got != "day2" {
+		t.Errorf("range=%q", got)
+	}
+	fields, err := logger.ParseFieldMatcher([]string{"day=01", "i=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(collect(Query{Fields: fields})); got != "fail" {
+		t.Errorf("fields=%q", got)
+	}
+}
+
+func TestQueryLogfmt(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := logger.ParseConfig([]byte(`logging:
+  web:
+    handler:
+      - typ: rotate_file
+        format: logfmt
+        filename: ` + dir + `/web.log
+        duration: 24h
+        encoder:
+          time_key: ts
+          level_key: lvl
+          message_key: message
+          name_key: name
+          caller_key: src
+          stacktrace_key: trace
+          level_format: capital_color`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources, err := Sources(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := logger.NewEncoder("logfmt", sources[0].Encoder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLog(t, dir+"/web.log.2024-01-01", enc, false,
+		zapcore.Entry{Time: at(1, 1), Level: zapcore.InfoLevel, LoggerName: "web", Message: `say "hi" a=b`, Caller: zapcore.NewEntryCaller(0, "/x/svc/a.go", 10, true)},
+		zapcore.Entry{Time: at(1, 2), Level: zapcore.ErrorLevel, Message: "fail", Stack: "main.go:1\nmain.go:2"},
+	)
+	writeLog(t, dir+"/web.log", enc, false,
+		zapcore.Entry{Time: at(2, 1), Level: zapcore.WarnLevel, Message: "day2"},
+	)
+
+	res := make([]Entry, 0)
+	q := Query{}
+	if err := q.Run(sources, func(ent Entry) error {
+		res = append(res, ent)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 3 {
+		t.Fatalf("entries=%+v", res)
+	}
+	first := res[0]
+	if first.Message != `say "hi" a=b` || first.Level != zapcore.InfoLevel || first.Logger != "web" || first.Caller != "svc/a.go:10" || !first.Time.Equal(at(1, 1)) {
+		t.Errorf("first=%+v", first)
+	}
+	if first.Fields["day"] != "01" || first.Fields["i"] != "0" || len(first.Fields) != 2 {
+		t.Errorf("fields=%+v", first.Fields)
+	}
+	if res[1].Stack != "main.go:1\nmain.go:2" || res[1].Level != zapcore.ErrorLevel {
+		t.Errorf("stack entry=%+v", res[1])
+	}
+	if res[2].Message != "day2" || res[2].Module != "web" {
+		t.Errorf("current entry=%+v", res[2])
+	}
+}
+
+func TestParserTimeFormats(t *testing.T) {
+	for _, format := range []string{"rfc3339nano", "epoch", "epoch_millis", "epoch_nanos", "2006/01/02 15:04:05.000"} {
+		cfg := &logger.EncoderConfig{TimeFormat: format, LevelFormat: "capital_color"}
+		for _, typ := range []string{"console", "json", "logfmt"} {
+			enc, err := logger.NewEncoder(typ, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.Local)
+			buf, _ := enc.EncodeEntry(zapcore.Entry{Time: want, Level: zapcore.WarnLevel, Message: "m"}, nil)
+			p, err := NewParser(typ, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ent, err := p.Parse(buf.String())
+			if err != nil || ent.Level != zapcore.WarnLevel || ent.Message != "m" || ent.Time.Sub(want).Abs() > time.Microsecond {
+				t.Errorf("%s/%s: %+v, %v (%q)", format, typ, ent, err, buf.String())
+			}
+		}
+	}
+}
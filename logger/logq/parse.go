@@ -0,0 +1,231 @@
+package logq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zero-miao/go-utils/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 23:50
+// @File   : parse.go
+// @Project: utils/logger/logq
+// ==========================
+
+// Entry 解析得到的一条日志.
+type Entry struct {
+	logger.MemoryEntry
+	Module string `json:"module,omitempty"`
+	File   string `json:"file,omitempty"`
+}
+
+// Parser 按 handler 的 format 和 encoder 配置解析日志行.
+type Parser struct {
+	Format string // console | json | logfmt, 为空时按行自动判断(以 { 开头为 json, 否则为 console)
+	keys   zapcore.EncoderConfig
+	layout string // 时间格式, 同 EncoderConfig.TimeFormat
+}
+
+func NewParser(format string, enc *logger.EncoderConfig) (*Parser, error) {
+	keys, err := enc.ZapConfig()
+	if err != nil {
+		return nil, err
+	}
+	p := &Parser{Format: format, keys: keys}
+	if enc != nil {
+		p.layout = enc.TimeFormat
+	}
+	switch format {
+	case "", "console", "json", "logfmt":
+	default:
+		return nil, errors.New("unsupported format: " + format)
+	}
+	return p, nil
+}
+
+var errContinuation = errors.New("continuation line")
+
+// Parse 解析一行日志. 不是日志开头的行(如 console 格式的调用栈)返回 errContinuation.
+func (p *Parser) Parse(line string) (Entry, error) {
+	line = strings.TrimRight(line, "\r\n")
+	format := p.Format
+	if format == "" {
+		format = "console"
+		if strings.HasPrefix(line, "{") {
+			format = "json"
+		}
+	}
+	switch format {
+	case "json":
+		return p.parseJSON(line)
+	case "logfmt":
+		return p.parseLogfmt(line)
+	}
+	return p.parseConsole(line)
+}
+
+func (p *Parser) parseJSON(line string) (Entry, error) {
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	var fields map[string]interface{}
+	if err := dec.Decode(&fields); err != nil {
+		return Entry{}, errContinuation
+	}
+	return p.parseFields(fields)
+}
+
+// parseLogfmt 解析 logger 的 logfmt 格式: 空格分隔的 key=value, 含特殊字符的值为 go 的双引号字符串.
+// 字段的值都是字符串, 嵌套的对象和数组为 json 字符串.
+func (p *Parser) parseLogfmt(line string) (Entry, error) {
+	fields := make(map[string]interface{})
+	for rest := strings.TrimLeft(line, " "); rest != ""; rest = strings.TrimLeft(rest, " ") {
+		i := strings.IndexByte(rest, '=')
+		if i <= 0 || strings.ContainsAny(rest[:i], " \"") {
+			return Entry{}, errContinuation
+		}
+		key, value := rest[:i], rest[i+1:]
+		if strings.HasPrefix(value, `"`) {
+			quoted, err := strconv.QuotedPrefix(value)
+			if err != nil {
+				return Entry{}, errContinuation
+			}
+			rest = value[len(quoted):]
+			value, _ = strconv.Unquote(quoted)
+		} else if j := strings.IndexByte(value, ' '); j >= 0 {
+			value, rest = value[:j], value[j:]
+		} else {
+			rest = ""
+		}
+		fields[key] = value
+	}
+	return p.parseFields(fields)
+}
+
+// parseFields 按 encoder 配置的 key 从 json, logfmt 的字段中取出时间, 等级等, 剩余的作为 Fields.
+func (p *Parser) parseFields(fields map[string]interface{}) (Entry, error) {
+	var ent Entry
+	take := func(key string) (interface{}, bool) {
+		if key == "" {
+			return nil, false
+		}
+		v, ok := fields[key]
+		delete(fields, key)
+		return v, ok
+	}
+	v, ok := take(p.keys.TimeKey)
+	if !ok {
+		return ent, errContinuation
+	}
+	t, err := p.parseTime(fmt.Sprint(v))
+	if err != nil {
+		return ent, err
+	}
+	ent.Time = t
+	if v, ok := take(p.keys.LevelKey); ok {
+		if ent.Level, err = parseLevel(fmt.Sprint(v)); err != nil {
+			return ent, err
+		}
+	}
+	if v, ok := take(p.keys.NameKey); ok {
+		ent.Logger = fmt.Sprint(v)
+	}
+	if v, ok := take(p.keys.CallerKey); ok {
+		ent.Caller = fmt.Sprint(v)
+	}
+	if v, ok := take(p.keys.MessageKey); ok {
+		ent.Message = fmt.Sprint(v)
+	}
+	if v, ok := take(p.keys.StacktraceKey); ok {
+		ent.Stack = fmt.Sprint(v)
+	}
+	if len(fields) > 0 {
+		ent.Fields = fields
+	}
+	return ent, nil
+}
+
+var callerPattern = regexp.MustCompile(`^\S+\.go:\d+$`)
+
+// parseConsole 解析 zap console 格式: 时间 等级 [logger] [caller] 内容 [json 字段], 以 tab 分隔.
+func (p *Parser) parseConsole(line string) (Entry, error) {
+	var ent Entry
+	parts := strings.Split(line, "\t")
+	if len(parts) < 2 {
+		return ent, errContinuation
+	}
+	t, err := p.parseTime(parts[0])
+	if err != nil {
+		return ent, errContinuation
+	}
+	level, err := parseLevel(parts[1])
+	if err != nil {
+		return ent, errContinuation
+	}
+	ent.Time, ent.Level = t, level
+	rest := parts[2:]
+	if n := len(rest); n > 0 && strings.HasPrefix(rest[n-1], "{") {
+		dec := json.NewDecoder(strings.NewReader(rest[n-1]))
+		dec.UseNumber()
+		var fields map[string]interface{}
+		if dec.Decode(&fields) == nil {
+			ent.Fields = fields
+			rest = rest[:n-1]
+		}
+	}
+	switch {
+	case len(rest) > 2 && callerPattern.MatchString(rest[1]):
+		ent.Logger, ent.Caller, rest = rest[0], rest[1], rest[2:]
+	case len(rest) > 1 && callerPattern.MatchString(rest[0]):
+		ent.Caller, rest = rest[0], rest[1:]
+	}
+	ent.Message = strings.Join(rest, "\t")
+	return ent, nil
+}
+
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func parseLevel(s string) (zapcore.Level, error) {
+	var level zapcore.Level
+	err := level.UnmarshalText(bytes.ToLower([]byte(ansiPattern.ReplaceAllString(s, ""))))
+	return level, err
+}
+
+var timeLayouts = map[string]string{
+	"":            "2006-01-02T15:04:05.000Z0700",
+	"iso8601":     "2006-01-02T15:04:05.000Z0700",
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+}
+
+func (p *Parser) parseTime(s string) (time.Time, error) {
+	switch p.layout {
+	case "epoch", "epoch_millis":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if p.layout == "epoch_millis" {
+			f /= 1e3
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)), nil
+	case "epoch_nanos":
+		n, err := strconv.ParseInt(s, 10, 64)
+		return time.Unix(0, n), err
+	}
+	layout, ok := timeLayouts[p.layout]
+	if !ok {
+		layout = p.layout
+	}
+	return time.ParseInLocation(layout, s, time.Local)
+}
@@ -0,0 +1,271 @@
+package logq
+
+import (
+	"bufio"
+	"compress/gzip"
+	"container/heap"
+	"io"
+	"os"
+	"time"
+
+	"github.com/zero-miao/go-utils/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-20 00:10
+// @File   : query.go
+// @Project: utils/logger/logq
+// ==========================
+
+// Query 查询条件, 零值表示不限制.
+type Query struct {
+	Since, Until time.Time            // [Since, Until)
+	Level        zapcore.LevelEnabler // 如 zapcore.WarnLevel 表示 warn 及以上, nil 表示不限制
+	Modules      []string
+	Fields       logger.FieldMatcher // 语法同 handler.filter.fields, 见 logger.ParseFieldMatcher
+}
+
+func (q *Query) matchModule(module string) bool {
+	if len(q.Modules) == 0 {
+		return true
+	}
+	for _, m := range q.Modules {
+		if m == module {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFile 跳过切割周期与时间范围没有交集的历史文件.
+func (q *Query) matchFile(f File) bool {
+	if f.End.IsZero() {
+		return true
+	}
+	if !q.Since.IsZero() && !f.End.After(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !f.Start.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// Match 判断日志是否满足条件.
+func (q *Query) Match(ent Entry) bool {
+	if !q.Since.IsZero() && ent.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !ent.Time.Before(q.Until) {
+		return false
+	}
+	if q.Level != nil && !q.Level.Enabled(ent.Level) {
+		return false
+	}
+	if len(q.Fields) > 0 {
+		values := make(map[string]string, len(ent.Fields)+2)
+		for key := range ent.Fields {
+			values[key], _ = ent.Field(key)
+		}
+		if ent.Logger != "" {
+			values["logger"] = ent.Logger
+		}
+		return q.Fields.Match(values)
+	}
+	return true
+}
+
+// Run 按时间顺序读取 sources 的所有文件, 对满足条件的日志调用 fn, fn 返回 error 时停止.
+// 每个 source 内的文件依次读取, 多个 source 之间按日志时间合并.
+func (q *Query) Run(sources []Source, fn func(Entry) error) error {
+	h := make(entryHeap, 0, len(sources))
+	defer func() {
+		for _, s := range h {
+			s.close()
+		}
+	}()
+	for _, src := range sources {
+		if !q.matchModule(src.Module) {
+			continue
+		}
+		s, err := newStream(src, q)
+		if err != nil {
+			return err
+		}
+		ok, err := s.next()
+		if err != nil {
+			s.close()
+			return err
+		}
+		if ok {
+			h = append(h, s)
+		} else {
+			s.close()
+		}
+	}
+	heap.Init(&h)
+	for len(h) > 0 {
+		s := h[0]
+		if err := fn(s.cur); err != nil {
+			return err
+		}
+		ok, err := s.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(&h, 0)
+		} else {
+			s.close()
+			heap.Pop(&h)
+		}
+	}
+	return nil
+}
+
+// stream 依次读取一个 source 的所有文件, 每次返回一条满足条件的日志.
+type stream struct {
+	src    Source
+	q      *Query
+	parser *Parser
+	files  []File
+
+	file    File
+	closer  io.Closer
+	scanner *bufio.Scanner
+	pending *Entry // 已读取, 等待后续调用栈行的日志
+	cur     Entry
+}
+
+func newStream(src Source, q *Query) (*stream, error) {
+	parser, err := NewParser(src.Format, src.Encoder)
+	if err != nil {
+		return nil, err
+	}
+	files, err := src.Files()
+	if err != nil {
+		return nil, err
+	}
+	s := &stream{src: src, q: q, parser: parser}
+	for _, f := range files {
+		if q.matchFile(f) {
+			s.files = append(s.files, f)
+		}
+	}
+	return s, nil
+}
+
+func (s *stream) open() (bool, error) {
+	if s.closer != nil {
+		s.closer.Close()
+		s.closer, s.scanner = nil, nil
+	}
+	if len(s.files) == 0 {
+		return false, nil
+	}
+	s.file, s.files = s.files[0], s.files[1:]
+	fp, err := os.Open(s.file.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// 读取期间被切割或清理.
+			return s.open()
+		}
+		return false, err
+	}
+	var r io.Reader = fp
+	s.closer = fp
+	if s.file.Compressed {
+		gz, err := gzip.NewReader(fp)
+		if err != nil {
+			fp.Close()
+			return false, err
+		}
+		r = gz
+	}
+	s.scanner = bufio.NewScanner(r)
+	s.scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return true, nil
+}
+
+// read 返回下一条完整的日志(包括之后的调用栈行).
+func (s *stream) read() (*Entry, error) {
+	for {
+		if s.scanner == nil {
+			ok, err := s.open()
+			if err != nil || !ok {
+				ent := s.pending
+				s.pending = nil
+				return ent, err
+			}
+		}
+		if !s.scanner.Scan() {
+			if err := s.scanner.Err(); err != nil {
+				return nil, err
+			}
+			// 日志不会跨文件, 文件结束时返回等待中的日志.
+			s.scanner = nil
+			if s.pending != nil {
+				ent := s.pending
+				s.pending = nil
+				return ent, nil
+			}
+			continue
+		}
+		line := s.scanner.Text()
+		ent, err := s.parser.Parse(line)
+		if err != nil {
+			if s.pending != nil {
+				if s.pending.Stack != "" {
+					s.pending.Stack += "\n"
+				}
+				s.pending.Stack += line
+			}
+			continue
+		}
+		ent.Module, ent.File = s.src.Module, s.file.Path
+		prev := s.pending
+		s.pending = &ent
+		if prev != nil {
+			return prev, nil
+		}
+	}
+}
+
+func (s *stream) next() (bool, error) {
+	for {
+		ent, err := s.read()
+		if err != nil || ent == nil {
+			return false, err
+		}
+		if !s.q.Until.IsZero() && !ent.Time.Before(s.q.Until) && s.file.End.IsZero() {
+			// 当前文件按时间写入, 之后的日志都不满足.
+			return false, nil
+		}
+		if s.q.Match(*ent) {
+			s.cur = *ent
+			return true, nil
+		}
+	}
+}
+
+func (s *stream) close() {
+	if s.closer != nil {
+		s.closer.Close()
+		s.closer = nil
+	}
+}
+
+type entryHeap []*stream
+
+func (h entryHeap) Len() int            { return len(h) }
+func (h entryHeap) Less(i, j int) bool  { return h[i].cur.Time.Before(h[j].cur.Time) }
+func (h entryHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *entryHeap) Push(x interface{}) { *h = append(*h, x.(*stream)) }
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	s := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return s
+}
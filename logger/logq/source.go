@@ -0,0 +1,130 @@
+package logq
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zero-miao/go-utils/logger"
+	"gopkg.in/yaml.v2"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 23:40
+// @File   : source.go
+// @Project: utils/logger/logq
+// ==========================
+
+// Source 一个写文件的 handler(file, rotate_file), 由配置得到文件名, 切割格式和日志格式.
+type Source struct {
+	Module   string
+	Handler  string // handler 的 name, 默认 typ.下标, 同 logger.Stats
+	Filename string
+	Layout   string        // 历史文件后缀的时间格式, 为空表示不切割
+	Duration time.Duration // 切割间隔
+	Format   string        // console | json | logfmt
+	Encoder  *logger.EncoderConfig
+	Archives []string // archive hook 的目录, 其中的历史文件同样读取
+}
+
+// Sources 返回配置中所有写文件的 handler, 按 module, handler 排序. 与 Config.Transform 使用相同的切割格式(logger.LayoutFor).
+func Sources(cfg *logger.Config) ([]Source, error) {
+	res := make([]Source, 0)
+	for module, item := range cfg.Logging {
+		for i, h := range item.Handler {
+			if h.Typ != "file" && h.Typ != "rotate_file" {
+				continue
+			}
+			if h.Filename == "" {
+				return nil, fmt.Errorf("module %s, handler[%d](%s): filename required", module, i, h.Typ)
+			}
+			src := Source{Module: module, Handler: h.Name, Filename: h.Filename, Format: h.Format, Encoder: h.Encoder}
+			if src.Handler == "" {
+				src.Handler = fmt.Sprintf("%s.%d", h.Typ, i)
+			}
+			if h.Typ == "rotate_file" {
+				du, err := time.ParseDuration(h.Duration)
+				if err != nil {
+					return nil, fmt.Errorf("module %s, handler[%d](%s): invalid duration: %s", module, i, h.Typ, h.Duration)
+				}
+				src.Duration, src.Layout = du, logger.LayoutFor(du)
+				src.Archives = archiveDirs(h.Hooks)
+			}
+			res = append(res, src)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Module != res[j].Module {
+			return res[i].Module < res[j].Module
+		}
+		return res[i].Handler < res[j].Handler
+	})
+	return res, nil
+}
+
+func archiveDirs(hooks []yaml.MapSlice) []string {
+	res := make([]string, 0)
+	for _, hook := range hooks {
+		var typ, dir string
+		for _, item := range hook {
+			switch item.Key {
+			case "typ":
+				typ, _ = item.Value.(string)
+			case "dir":
+				dir, _ = item.Value.(string)
+			}
+		}
+		if typ == "archive" && dir != "" {
+			res = append(res, dir)
+		}
+	}
+	return res
+}
+
+// File 一个日志文件. 历史文件的 Start 为切割周期的开始时间, 当前文件的 Start 为零值.
+type File struct {
+	Path       string
+	Start      time.Time
+	End        time.Time // 为零值表示当前正在写入的文件
+	Compressed bool      // .gz
+}
+
+// Files 返回所有历史文件和当前文件, 按时间从早到晚. 同一周期在原目录和归档目录都存在时只读取原目录的文件.
+func (s Source) Files() ([]File, error) {
+	res := make([]File, 0)
+	if s.Layout != "" {
+		seen := map[time.Time]bool{}
+		base := filepath.Base(s.Filename)
+		for _, dir := range append([]string{filepath.Dir(s.Filename)}, s.Archives...) {
+			matches, err := filepath.Glob(filepath.Join(dir, base+".*"))
+			if err != nil {
+				return nil, err
+			}
+			sort.Strings(matches)
+			for _, path := range matches {
+				suffix := filepath.Base(path)[len(base)+1:]
+				compressed := strings.HasSuffix(suffix, ".gz")
+				suffix = strings.TrimSuffix(suffix, ".gz")
+				// 切割时文件名中的时间为 UTC, 见 logger.Retention
+				t, err := time.ParseInLocation(s.Layout, suffix, time.UTC)
+				if err != nil || seen[t] {
+					continue
+				}
+				seen[t] = true
+				res = append(res, File{Path: path, Start: t, End: t.Add(s.Duration), Compressed: compressed})
+			}
+		}
+		sort.SliceStable(res, func(i, j int) bool { return res[i].Start.Before(res[j].Start) })
+	}
+	if _, err := os.Stat(s.Filename); err == nil {
+		res = append(res, File{Path: s.Filename, Compressed: strings.HasSuffix(s.Filename, ".gz")})
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return res, nil
+}
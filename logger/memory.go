@@ -0,0 +1,287 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 19:10
+// @File   : memory.go
+// @Project: utils/logger
+// ==========================
+
+// DefaultMemorySize memory handler 默认保留的条数.
+const DefaultMemorySize = 1000
+
+// memoryEncoderConfig memory handler 固定使用的 json 格式, 写入后再解析为 MemoryEntry.
+var memoryEncoderConfig = zapcore.EncoderConfig{
+	TimeKey:        "ts",
+	LevelKey:       "level",
+	NameKey:        "logger",
+	CallerKey:      "caller",
+	MessageKey:     "msg",
+	StacktraceKey:  "stack",
+	LineEnding:     zapcore.DefaultLineEnding,
+	EncodeLevel:    zapcore.LowercaseLevelEncoder,
+	EncodeTime:     zapcore.EpochNanosTimeEncoder,
+	EncodeDuration: zapcore.StringDurationEncoder,
+	EncodeCaller:   zapcore.ShortCallerEncoder,
+}
+
+// MemoryEntry memory handler 记录的日志.
+type MemoryEntry struct {
+	Time    time.Time              `json:"time"`
+	Level   zapcore.Level          `json:"level"`
+	Logger  string                 `json:"logger,omitempty"`
+	Message string                 `json:"msg"`
+	Caller  string                 `json:"caller,omitempty"`
+	Stack   string                 `json:"stack,omitempty"`
+	Fields  map[string]interface{} `json:"fields,omitempty"` // 数字为 json.Number
+}
+
+// Field 返回字段值的字符串形式, 字段不存在时 ok 为 false.
+func (e MemoryEntry) Field(key string) (string, bool) {
+	v, ok := e.Fields[key]
+	if !ok {
+		return "", false
+	}
+	switch value := v.(type) {
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v), true
+	}
+	return string(data), true
+}
+
+// MemoryLog 有界的环形缓冲区, 保存最近的日志. 可以直接作为 io.Writer 接收 memory 格式(json)的日志.
+type MemoryLog struct {
+	mu      sync.RWMutex
+	entries []MemoryEntry
+	next    int
+	full    bool
+}
+
+func NewMemoryLog(size int) *MemoryLog {
+	if size <= 0 {
+		size = DefaultMemorySize
+	}
+	return &MemoryLog{entries: make([]MemoryEntry, size)}
+}
+
+func (m *MemoryLog) Write(p []byte) (int, error) {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	var fields map[string]interface{}
+	if err := dec.Decode(&fields); err != nil {
+		return 0, err
+	}
+	ent := MemoryEntry{}
+	if ts, ok := fields["ts"].(json.Number); ok {
+		if n, err := ts.Int64(); err == nil {
+			ent.Time = time.Unix(0, n)
+		}
+	}
+	if level, ok := fields["level"].(string); ok {
+		_ = ent.Level.UnmarshalText([]byte(level))
+	}
+	ent.Logger, _ = fields["logger"].(string)
+	ent.Message, _ = fields["msg"].(string)
+	ent.Caller, _ = fields["caller"].(string)
+	ent.Stack, _ = fields["stack"].(string)
+	for _, key := range []string{"ts", "level", "logger", "msg", "caller", "stack"} {
+		delete(fields, key)
+	}
+	if len(fields) > 0 {
+		ent.Fields = fields
+	}
+	m.mu.Lock()
+	m.entries[m.next] = ent
+	m.next = (m.next + 1) % len(m.entries)
+	if m.next == 0 {
+		m.full = true
+	}
+	m.mu.Unlock()
+	return len(p), nil
+}
+
+// Len 返回当前保存的条数.
+func (m *MemoryLog) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.full {
+		return len(m.entries)
+	}
+	return m.next
+}
+
+// Entries 返回所有日志, 按时间顺序.
+func (m *MemoryLog) Entries() []MemoryEntry {
+	return m.Last(0)
+}
+
+// Last 返回最近的 n 条日志, 按时间顺序. n <= 0 时返回全部.
+func (m *MemoryLog) Last(n int) []MemoryEntry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var all []MemoryEntry
+	if m.full {
+		all = append(all, m.entries[m.next:]...)
+	}
+	all = append(all, m.entries[:m.next]...)
+	if n > 0 && n < len(all) {
+		all = all[len(all)-n:]
+	}
+	return all
+}
+
+// Reset 清空日志.
+func (m *MemoryLog) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.entries {
+		m.entries[i] = MemoryEntry{}
+	}
+	m.next = 0
+	m.full = false
+}
+
+// Filter 返回满足条件的日志, 按时间顺序.
+func (m *MemoryLog) Filter(match func(MemoryEntry) bool) []MemoryEntry {
+	var res []MemoryEntry
+	for _, ent := range m.Entries() {
+		if match(ent) {
+			res = append(res, ent)
+		}
+	}
+	return res
+}
+
+// ByLevel 返回等级为 level 及以上的日志.
+func (m *MemoryLog) ByLevel(level zapcore.Level) []MemoryEntry {
+	return m.Filter(func(ent MemoryEntry) bool { return ent.Level >= level })
+}
+
+// ByMessage 返回内容包含 substr 的日志.
+func (m *MemoryLog) ByMessage(substr string) []MemoryEntry {
+	return m.Filter(func(ent MemoryEntry) bool { return strings.Contains(ent.Message, substr) })
+}
+
+// ByField 返回字段 key 的值为 value 的日志, 非字符串字段按 json 格式比较, 如 ByField("status", "200").
+func (m *MemoryLog) ByField(key, value string) []MemoryEntry {
+	return m.Filter(func(ent MemoryEntry) bool {
+		v, ok := ent.Field(key)
+		return ok && v == value
+	})
+}
+
+var (
+	memoryLogsMu sync.RWMutex
+	memoryLogs   = map[string]*MemoryLog{}
+)
+
+// Memory 返回 module 的 memory handler 记录的日志, 没有配置时返回 nil.
+// 同一个 module 配置了多个 memory handler 时, 返回最后一个.
+func Memory(module string) *MemoryLog {
+	memoryLogsMu.RLock()
+	defer memoryLogsMu.RUnlock()
+	return memoryLogs[module]
+}
+
+// MemoryHandler 把日志保存在内存中, 用于测试断言以及 MemoryHTTPHandler.
+type MemoryHandler struct {
+	Log   *MemoryLog
+	Level zapcore.Level
+}
+
+func (h *MemoryHandler) BuildWriter() (io.Writer, error) {
+	return h.Log, nil
+}
+
+func (h *MemoryHandler) GetLevel() zapcore.Level {
+	return h.Level
+}
+
+// GetFormat memory handler 固定使用 json 格式, 忽略 format 配置.
+func (h *MemoryHandler) GetFormat() zapcore.Encoder {
+	return zapcore.NewJSONEncoder(memoryEncoderConfig)
+}
+
+func memoryFactory(node *RawNode) (Handler, error) {
+	var cfg HandlerConfig
+	if err := node.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Size < 0 {
+		return nil, fmt.Errorf("invalid memory size: %d", cfg.Size)
+	}
+	log := NewMemoryLog(cfg.Size)
+	memoryLogsMu.Lock()
+	memoryLogs[node.Module] = log
+	memoryLogsMu.Unlock()
+	return &MemoryHandler{Log: log, Level: level}, nil
+}
+
+// MemoryHTTPHandler 以 json 返回 module 最近的日志, 参数:
+//
+//	module 必填; n 条数, 默认 100; level 最低等级; msg 内容包含的字符串.
+//
+// 例如: http.Handle("/debug/logs", logger.MemoryHTTPHandler()), GET /debug/logs?module=app&n=50&level=warn
+func MemoryHTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		module := query.Get("module")
+		log := Memory(module)
+		if log == nil {
+			http.Error(w, "no memory handler for module: "+module, http.StatusNotFound)
+			return
+		}
+		n := 100
+		if s := query.Get("n"); s != "" {
+			var err error
+			if n, err = strconv.Atoi(s); err != nil || n <= 0 {
+				http.Error(w, "invalid n: "+s, http.StatusBadRequest)
+				return
+			}
+		}
+		level := zapcore.DebugLevel
+		if s := query.Get("level"); s != "" {
+			var ok bool
+			if level, ok = LogLevelMap[s]; !ok {
+				http.Error(w, "invalid level: "+s, http.StatusBadRequest)
+				return
+			}
+		}
+		msg := query.Get("msg")
+		entries := log.Filter(func(ent MemoryEntry) bool {
+			return ent.Level >= level && strings.Contains(ent.Message, msg)
+		})
+		if len(entries) > n {
+			entries = entries[len(entries)-n:]
+		}
+		if entries == nil {
+			entries = []MemoryEntry{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(entries)
+	})
+}
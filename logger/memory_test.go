@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestMemoryLog(t *testing.T) {
+	m := NewMemoryLog(3)
+	l := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(memoryEncoderConfig), zapcore.AddSync(m), zapcore.DebugLevel))
+	for i := 0; i < 5; i++ {
+		l.Info("loop", zap.Int("i", i))
+	}
+	if m.Len() != 3 {
+		t.Fatalf("len=%v, want 3", m.Len())
+	}
+	entries := m.Entries()
+	if v, _ := entries[0].Field("i"); v != "2" {
+		t.Errorf("oldest i=%v, want 2", v)
+	}
+	if last := m.Last(1); len(last) != 1 || last[0].Fields["i"] != json.Number("4") {
+		t.Errorf("last=%+v", last)
+	}
+	m.Reset()
+	if m.Len() != 0 || len(m.Entries()) != 0 {
+		t.Errorf("reset len=%v", m.Len())
+	}
+}
+
+func TestMemoryHandler(t *testing.T) {
+	YamlInit([]byte(`logging:
+  memory:
+    handler:
+      - typ: memory
+        level: info
+        size: 10`))
+	m := Memory("memory")
+	if m == nil {
+		t.Fatal("memory log not found")
+	}
+	l := L("memory").Named("payment")
+	l.Debug("skip")
+	l.Info("paid", zap.String("order", "o1"), zap.Int("amount", 100))
+	l.Warn("slow", zap.Duration("cost", time.Second))
+	l.Error("failed", zap.Error(errors.New("timeout")), zap.String("order", "o2"))
+
+	if n := len(m.Entries()); n != 3 {
+		t.Fatalf("entries=%v, want 3", n)
+	}
+	if res := m.ByLevel(zapcore.WarnLevel); len(res) != 2 || res[0].Message != "slow" {
+		t.Errorf("by level=%+v", res)
+	}
+	if res := m.ByMessage("pai"); len(res) != 1 || res[0].Logger != "payment" || res[0].Time.IsZero() {
+		t.Errorf("by message=%+v", res)
+	}
+	if res := m.ByField("amount", "100"); len(res) != 1 || res[0].Message != "paid" {
+		t.Errorf("by field=%+v", res)
+	}
+	if res := m.ByField("order", "o2"); len(res) != 1 || res[0].Fields["error"] != "timeout" {
+		t.Errorf("by field=%+v", res)
+	}
+
+	srv := httptest.NewServer(MemoryHTTPHandler())
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL + "?module=memory&n=1&level=warn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var got []MemoryEntry
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Message != "failed" || got[0].Level != zapcore.ErrorLevel {
+		t.Errorf("http got %+v", got)
+	}
+	resp, err = srv.Client().Get(srv.URL + "?module=unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 404 {
+		t.Errorf("status=%v, want 404", resp.StatusCode)
+	}
+}
+
+func TestMemoryReinit(t *testing.T) {
+	cfg := []byte(`logging:
+  memory_reinit:
+    handler:
+      - typ: memory
+        level: debug`)
+	YamlInit(cfg)
+	L("memory_reinit").Info("first")
+	YamlInit(cfg)
+	L("memory_reinit").Info("second")
+	if res := Memory("memory_reinit").Entries(); len(res) != 1 || res[0].Message != "second" {
+		t.Errorf("entries after reinit=%+v", res)
+	}
+}
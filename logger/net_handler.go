@@ -0,0 +1,317 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 13:10
+// @File   : net_handler.go
+// @Project: utils/logger
+// ==========================
+
+var errReconnecting = errors.New("connection lost, waiting to reconnect")
+
+// netWriter 网络连接 writer. 连接断开时自动重连, 连续失败时按指数退避(最长 30s), 退避期间的日志直接返回错误.
+type netWriter struct {
+	mu      sync.Mutex
+	network string
+	address string
+	timeout time.Duration
+	conn    net.Conn
+	backoff time.Duration
+	retryAt time.Time
+}
+
+func newNetWriter(network, address string, timeout time.Duration) *netWriter {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &netWriter{network: network, address: address, timeout: timeout}
+}
+
+func (w *netWriter) dial() error {
+	if w.conn != nil {
+		return nil
+	}
+	now := time.Now()
+	if now.Before(w.retryAt) {
+		return errReconnecting
+	}
+	conn, err := net.DialTimeout(w.network, w.address, w.timeout)
+	if err != nil {
+		if w.backoff == 0 {
+			w.backoff = 100 * time.Millisecond
+		} else if w.backoff < 30*time.Second {
+			w.backoff *= 2
+		}
+		w.retryAt = now.Add(w.backoff)
+		return err
+	}
+	w.conn = conn
+	w.backoff = 0
+	return nil
+}
+
+func (w *netWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.write(p)
+}
+
+// write 写入失败时重新连接并重试一次.
+func (w *netWriter) write(p []byte) (int, error) {
+	var err error
+	for i := 0; i < 2; i++ {
+		if err = w.dial(); err != nil {
+			return 0, err
+		}
+		w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+		var n int
+		n, err = w.conn.Write(p)
+		if err == nil {
+			return n, nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	return 0, err
+}
+
+func (w *netWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// NetHandler tcp/udp 按行发送日志(每条日志以换行结尾, udp 每条日志一个数据包).
+type NetHandler struct {
+	Network string // tcp | udp
+	Address string
+	Timeout time.Duration
+	Level   zapcore.Level
+	Format  zapcore.Encoder
+	Buffer  *BufferOption // 非空时异步写入
+}
+
+func (h *NetHandler) BuildWriter() (io.Writer, error) {
+	if h.Address == "" {
+		return nil, errors.New("empty address")
+	}
+	w := newNetWriter(h.Network, h.Address, h.Timeout)
+	if h.Buffer != nil {
+		return registerBuffered(h.Network+"://"+h.Address, NewBufferedWriter(w, *h.Buffer)), nil
+	}
+	return w, nil
+}
+
+func (h *NetHandler) GetLevel() zapcore.Level {
+	return h.Level
+}
+
+func (h *NetHandler) GetFormat() zapcore.Encoder {
+	return h.Format
+}
+
+var SyslogFacilityMap = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+var syslogSeverityMap = map[zapcore.Level]int{
+	zapcore.DebugLevel:  7,
+	zapcore.InfoLevel:   6,
+	zapcore.WarnLevel:   4,
+	zapcore.ErrorLevel:  3,
+	zapcore.DPanicLevel: 2,
+	zapcore.PanicLevel:  1,
+	zapcore.FatalLevel:  0,
+}
+
+// SyslogHandler 以 RFC5424 格式发送到 syslog.
+// network 为 unix/unixgram 时 address 为 socket 路径(如 /dev/log), tcp 使用 octet-counting 分帧(RFC6587).
+type SyslogHandler struct {
+	Network  string // unix | unixgram | udp | tcp
+	Address  string
+	Facility int
+	AppName  string
+	Timeout  time.Duration
+	Level    zapcore.Level
+	Format   zapcore.Encoder
+}
+
+func (h *SyslogHandler) BuildWriter() (io.Writer, error) {
+	if h.Address == "" {
+		return nil, errors.New("empty address")
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	appName := h.AppName
+	if appName == "" {
+		appName = "-"
+	}
+	return &syslogWriter{
+		netWriter: newNetWriter(h.Network, h.Address, h.Timeout),
+		facility:  h.Facility,
+		hostname:  hostname,
+		appName:   appName,
+		pid:       os.Getpid(),
+	}, nil
+}
+
+func (h *SyslogHandler) GetLevel() zapcore.Level {
+	return h.Level
+}
+
+func (h *SyslogHandler) GetFormat() zapcore.Encoder {
+	return h.Format
+}
+
+type syslogWriter struct {
+	*netWriter
+	facility int
+	hostname string
+	appName  string
+	pid      int
+}
+
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zapcore.InfoLevel, p)
+}
+
+// WriteLevel 格式: <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (w *syslogWriter) WriteLevel(level zapcore.Level, p []byte) (int, error) {
+	severity, ok := syslogSeverityMap[level]
+	if !ok {
+		severity = 6
+	}
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d - - %s", w.facility*8+severity,
+		time.Now().Format("2006-01-02T15:04:05.000000Z07:00"), w.hostname, w.appName, w.pid, bytes.TrimRight(p, "\n"))
+	switch w.network {
+	case "tcp", "tcp4", "tcp6":
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	case "unix":
+		msg += "\n"
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.write([]byte(msg)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+const (
+	HTTPStyleNDJSON = "ndjson"  // 每行一条 json 日志, 如 vector, fluent-bit 的 http 输入.
+	HTTPStyleESBulk = "es_bulk" // Elasticsearch _bulk 接口, 每条日志前追加 {"index":{}}.
+)
+
+// HTTPHandler 批量 POST 日志到 URL, 日志格式应当为 json.
+// 日志先写入缓冲区, 满 BatchSize 条或者每隔 FlushInterval 由后台协程发送一次; error 及以上等级通知后台协程立即发送.
+// 缓冲区可容纳 10 批, 发送跟不上时优先丢弃 debug/info 日志(drop_low).
+type HTTPHandler struct {
+	URL           string
+	Headers       map[string]string
+	Style         string // ndjson | es_bulk
+	BatchSize     int
+	FlushInterval time.Duration
+	Timeout       time.Duration
+	Level         zapcore.Level
+	Format        zapcore.Encoder
+}
+
+func (h *HTTPHandler) BuildWriter() (io.Writer, error) {
+	if h.URL == "" {
+		return nil, errors.New("empty url")
+	}
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	poster := &httpPoster{
+		url:     h.URL,
+		headers: h.Headers,
+		style:   h.Style,
+		client:  &http.Client{Timeout: timeout},
+	}
+	opt := BufferOption{BatchSize: h.BatchSize, FlushInterval: h.FlushInterval, Overflow: OverflowDropLow, AsyncError: true}
+	if opt.BatchSize <= 0 {
+		opt.BatchSize = 100
+	}
+	opt.Size = opt.BatchSize * 10
+	if opt.FlushInterval <= 0 {
+		opt.FlushInterval = time.Second
+	}
+	return registerBuffered(h.URL, NewBufferedWriter(poster, opt)), nil
+}
+
+func (h *HTTPHandler) GetLevel() zapcore.Level {
+	return h.Level
+}
+
+func (h *HTTPHandler) GetFormat() zapcore.Encoder {
+	return h.Format
+}
+
+// httpPoster 每次 Write 发送一个批次.
+type httpPoster struct {
+	url     string
+	headers map[string]string
+	style   string
+	client  *http.Client
+}
+
+func (p *httpPoster) Write(batch []byte) (int, error) {
+	body := batch
+	contentType := "application/x-ndjson"
+	if p.style == HTTPStyleESBulk {
+		lines := strings.Split(strings.TrimRight(string(batch), "\n"), "\n")
+		var buf bytes.Buffer
+		for _, line := range lines {
+			buf.WriteString("{\"index\":{}}\n")
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+		body = buf.Bytes()
+	}
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for key, value := range p.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("http log sink: %s %s", p.url, resp.Status)
+	}
+	return len(batch), nil
+}
@@ -0,0 +1,213 @@
+package logger
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNetHandler_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	lines := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			scanner := bufio.NewScanner(conn)
+			if scanner.Scan() {
+				lines <- scanner.Text()
+			}
+			conn.Close() // 每个连接只读一行, 迫使 writer 重连.
+		}
+	}()
+
+	h := &NetHandler{Network: "tcp", Address: ln.Addr().String(), Timeout: time.Second}
+	w, err := h.BuildWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-lines; got != "first" {
+		t.Errorf("got %q, want first", got)
+	}
+	// 服务端已关闭连接, 之后的写入会失败并重连(关闭后的第一次写入可能丢失).
+	deadline := time.After(2 * time.Second)
+	for {
+		w.Write([]byte("second\n"))
+		select {
+		case got := <-lines:
+			if got != "second" {
+				t.Errorf("got %q, want second", got)
+			}
+			return
+		case <-deadline:
+			t.Fatal("reconnect failed")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func TestNetHandler_UDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	h := &NetHandler{Network: "udp", Address: pc.LocalAddr().String()}
+	w, _ := h.BuildWriter()
+	w.Write([]byte("hello\n"))
+	buf := make([]byte, 1024)
+	pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil || string(buf[:n]) != "hello\n" {
+		t.Errorf("got %q, %v", buf[:n], err)
+	}
+}
+
+func TestSyslogHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syslog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "log.sock")
+	pc, err := net.ListenPacket("unixgram", sock)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer pc.Close()
+	h := &SyslogHandler{Network: "unixgram", Address: sock, Facility: SyslogFacilityMap["local0"], AppName: "test"}
+	w, err := h.BuildWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.(LevelWriter).WriteLevel(zapcore.ErrorLevel, []byte("boom\n"))
+	buf := make([]byte, 1024)
+	pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// local0(16)*8 + error(3) = 131
+	if !regexp.MustCompile(`^<131>1 \S+ \S+ test \d+ - - boom$`).Match(buf[:n]) {
+		t.Errorf("unexpected syslog message %q", buf[:n])
+	}
+}
+
+// httpSink 记录收到的请求体, delay 模拟较慢的服务端.
+type httpSink struct {
+	mu     sync.Mutex
+	bodies []string
+	delay  time.Duration
+}
+
+func (s *httpSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, _ := ioutil.ReadAll(r.Body)
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	s.bodies = append(s.bodies, string(data))
+	s.mu.Unlock()
+}
+
+// wait 等待收到 n 个请求, 返回收到的请求体.
+func (s *httpSink) wait(t *testing.T, n int) []string {
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.Lock()
+		bodies := append([]string(nil), s.bodies...)
+		s.mu.Unlock()
+		if len(bodies) >= n || time.Now().After(deadline) {
+			return bodies
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHTTPHandler(t *testing.T) {
+	sink := &httpSink{delay: 100 * time.Millisecond}
+	srv := httptest.NewServer(sink)
+	defer srv.Close()
+
+	h := &HTTPHandler{URL: srv.URL, Style: HTTPStyleESBulk, BatchSize: 10, FlushInterval: time.Hour}
+	w, err := h.BuildWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeWriter(w)
+	lw := w.(LevelWriter)
+	start := time.Now()
+	lw.WriteLevel(zapcore.InfoLevel, []byte(`{"msg_":"a"}`+"\n"))
+	lw.WriteLevel(zapcore.ErrorLevel, []byte(`{"msg_":"b"}`+"\n")) // error 由后台协程立即发送, 不阻塞写入方
+	if d := time.Since(start); d >= sink.delay {
+		t.Errorf("error write blocked for %v", d)
+	}
+	want := "{\"index\":{}}\n{\"msg_\":\"a\"}\n{\"index\":{}}\n{\"msg_\":\"b\"}\n"
+	if bodies := sink.wait(t, 1); len(bodies) != 1 || bodies[0] != want {
+		t.Errorf("got %q, want %q", bodies, want)
+	}
+}
+
+func TestHTTPHandlerBatch(t *testing.T) {
+	sink := &httpSink{}
+	srv := httptest.NewServer(sink)
+	defer srv.Close()
+
+	h := &HTTPHandler{URL: srv.URL, BatchSize: 2, FlushInterval: time.Hour}
+	w, err := h.BuildWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeWriter(w)
+	lw := w.(LevelWriter)
+	// 突发写入超过一批时按批发送, 不丢弃.
+	for i := 0; i < 5; i++ {
+		lw.WriteLevel(zapcore.InfoLevel, []byte(`{"i":`+strconv.Itoa(i)+`}`+"\n"))
+	}
+	bodies := sink.wait(t, 2)
+	if len(bodies) < 2 || bodies[0] != "{\"i\":0}\n{\"i\":1}\n" {
+		t.Fatalf("bodies=%q", bodies)
+	}
+	w.(*BufferedWriter).Sync()
+	bodies = sink.wait(t, 3)
+	if got := strings.Join(bodies, ""); strings.Count(got, "\n") != 5 || w.(*BufferedWriter).Dropped() != 0 {
+		t.Errorf("bodies=%q, dropped=%d", bodies, w.(*BufferedWriter).Dropped())
+	}
+	for _, body := range bodies {
+		if strings.Count(body, "\n") > 2 {
+			t.Errorf("batch larger than batch_size: %q", body)
+		}
+	}
+}
+
+func TestTransform_InvalidTyp(t *testing.T) {
+	defer func() {
+		err := recover()
+		if err == nil || !strings.Contains(err.(string), "invalid handler typ") {
+			t.Errorf("unexpected panic: %v", err)
+		}
+	}()
+	c := Config{Logging: map[string]ConfigHandler{
+		"app": {Handler: []HandlerConfig{{Typ: "fiel", Level: "info"}}},
+	}}
+	c.Transform()
+}
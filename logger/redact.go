@@ -0,0 +1,281 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 17:00
+// @File   : redact.go
+// @Project: utils/logger
+// ==========================
+
+// 脱敏方式
+const (
+	RedactFull    = "full"    // 全部替换为 ******
+	RedactPartial = "partial" // 保留首尾各 1/4, 中间替换为 *
+	RedactHash    = "hash"    // 替换为 sha256 的前 16 位
+)
+
+// RedactRule yaml 中 module.redact 的配置, field 和 value 至少填写一个.
+type RedactRule struct {
+	Field string `yaml:"field"` // 字段名正则, 完整匹配, 不区分大小写. 匹配的字段整体脱敏(包括嵌套对象中的 key)
+	Value string `yaml:"value"` // 值正则, 匹配的部分脱敏, 作用于字符串字段, error 以及日志内容
+	Mode  string `yaml:"mode"`  // full(默认) | partial | hash
+}
+
+type redactRule struct {
+	field *regexp.Regexp
+	value *regexp.Regexp
+	mode  string
+}
+
+// Redactor 按规则对日志内容和字段进行脱敏.
+type Redactor struct {
+	rules []redactRule
+}
+
+func NewRedactor(rules []RedactRule) (*Redactor, error) {
+	r := &Redactor{}
+	for i, rule := range rules {
+		item := redactRule{mode: rule.Mode}
+		switch item.mode {
+		case "":
+			item.mode = RedactFull
+		case RedactFull, RedactPartial, RedactHash:
+		default:
+			return nil, fmt.Errorf("redact[%d]: invalid mode: %s", i, rule.Mode)
+		}
+		if rule.Field == "" && rule.Value == "" {
+			return nil, fmt.Errorf("redact[%d]: field or value required", i)
+		}
+		var err error
+		if rule.Field != "" {
+			if item.field, err = regexp.Compile("(?i)^(?:" + rule.Field + ")$"); err != nil {
+				return nil, fmt.Errorf("redact[%d]: invalid field: %v", i, err)
+			}
+		}
+		if rule.Value != "" {
+			if item.value, err = regexp.Compile(rule.Value); err != nil {
+				return nil, fmt.Errorf("redact[%d]: invalid value: %v", i, err)
+			}
+		}
+		r.rules = append(r.rules, item)
+	}
+	return r, nil
+}
+
+// Mask 按 mode 对整个值脱敏.
+func Mask(value, mode string) string {
+	switch mode {
+	case RedactHash:
+		sum := sha256.Sum256([]byte(value))
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	case RedactPartial:
+		runes := []rune(value)
+		keep := len(runes) / 4
+		if keep == 0 {
+			return strings.Repeat("*", len(runes))
+		}
+		return string(runes[:keep]) + strings.Repeat("*", len(runes)-2*keep) + string(runes[len(runes)-keep:])
+	default:
+		return "******"
+	}
+}
+
+// fieldMode 返回字段名匹配的规则的脱敏方式.
+func (r *Redactor) fieldMode(key string) (string, bool) {
+	for _, rule := range r.rules {
+		if rule.field != nil && rule.field.MatchString(key) {
+			return rule.mode, true
+		}
+	}
+	return "", false
+}
+
+// String 对字符串中匹配 value 正则的部分脱敏.
+func (r *Redactor) String(s string) string {
+	for _, rule := range r.rules {
+		if rule.value != nil {
+			mode := rule.mode
+			s = rule.value.ReplaceAllStringFunc(s, func(m string) string { return Mask(m, mode) })
+		}
+	}
+	return s
+}
+
+// Fields 返回脱敏后的字段, 没有变化的字段保持原样.
+func (r *Redactor) Fields(fields []zapcore.Field) []zapcore.Field {
+	var res []zapcore.Field
+	for i, f := range fields {
+		nf, changed := r.field(f)
+		if !changed {
+			if res != nil {
+				res = append(res, f)
+			}
+			continue
+		}
+		if res == nil {
+			res = make([]zapcore.Field, i, len(fields))
+			copy(res, fields[:i])
+		}
+		res = append(res, nf)
+	}
+	if res == nil {
+		return fields
+	}
+	return res
+}
+
+func (r *Redactor) field(f zapcore.Field) (zapcore.Field, bool) {
+	if f.Type == zapcore.NamespaceType || f.Type == zapcore.SkipType {
+		return f, false
+	}
+	if mode, ok := r.fieldMode(f.Key); ok {
+		return zap.String(f.Key, Mask(fieldString(f), mode)), true
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		if s := r.String(f.String); s != f.String {
+			return zap.String(f.Key, s), true
+		}
+	case zapcore.ByteStringType:
+		if raw := string(f.Interface.([]byte)); r.String(raw) != raw {
+			return zap.String(f.Key, r.String(raw)), true
+		}
+	case zapcore.StringerType, zapcore.ErrorType:
+		raw := fieldString(f)
+		if s := r.String(raw); s != raw {
+			return zap.String(f.Key, s), true
+		}
+	case zapcore.ReflectType, zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.InlineMarshalerType:
+		m := zapcore.NewMapObjectEncoder()
+		f.AddTo(m)
+		var value interface{} = m.Fields
+		if f.Type != zapcore.InlineMarshalerType {
+			value = m.Fields[f.Key]
+		}
+		// 统一转换为 map/slice/基本类型后再遍历, 避免修改原始对象.
+		data, err := json.Marshal(value)
+		if err != nil {
+			return f, false
+		}
+		var plain interface{}
+		if err := json.Unmarshal(data, &plain); err != nil {
+			return f, false
+		}
+		if res, changed := r.walk(plain); changed {
+			if f.Type == zapcore.InlineMarshalerType {
+				return zap.Inline(inlineMap(res.(map[string]interface{}))), true
+			}
+			return zap.Any(f.Key, res), true
+		}
+	}
+	return f, false
+}
+
+func (r *Redactor) walk(v interface{}) (interface{}, bool) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		changed := false
+		for key, item := range value {
+			if mode, ok := r.fieldMode(key); ok {
+				value[key] = Mask(fmt.Sprint(item), mode)
+				changed = true
+				continue
+			}
+			if res, ok := r.walk(item); ok {
+				value[key] = res
+				changed = true
+			}
+		}
+		return value, changed
+	case []interface{}:
+		changed := false
+		for i, item := range value {
+			if res, ok := r.walk(item); ok {
+				value[i] = res
+				changed = true
+			}
+		}
+		return value, changed
+	case string:
+		s := r.String(value)
+		return s, s != value
+	}
+	return v, false
+}
+
+type inlineMap map[string]interface{}
+
+func (m inlineMap) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for key, value := range m {
+		if err := enc.AddReflected(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldString 返回字段值的字符串形式.
+func fieldString(f zapcore.Field) string {
+	switch f.Type {
+	case zapcore.StringType:
+		return f.String
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok && err != nil {
+			return err.Error()
+		}
+	case zapcore.StringerType:
+		if s, ok := f.Interface.(fmt.Stringer); ok {
+			return s.String()
+		}
+	}
+	m := zapcore.NewMapObjectEncoder()
+	f.AddTo(m)
+	if v, ok := m.Fields[f.Key]; ok {
+		if s, ok := v.(string); ok {
+			return s
+		}
+		if data, err := json.Marshal(v); err == nil {
+			return string(data)
+		}
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// redactCore 在写入前对日志内容和字段脱敏. 包装在每个 handler 的 core 最内层, 因此对所有 handler(包括邮件)生效.
+type redactCore struct {
+	zapcore.Core
+	r *Redactor
+}
+
+func (r *Redactor) WrapCore(core zapcore.Core) zapcore.Core {
+	return &redactCore{Core: core, r: r}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.r.Fields(fields)), r: c.r}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.r.String(ent.Message)
+	return c.Core.Write(ent, c.r.Fields(fields))
+}
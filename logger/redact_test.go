@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestMask(t *testing.T) {
+	if s := Mask("13812345678", RedactPartial); s != "13*******78" {
+		t.Errorf("partial mask=%s", s)
+	}
+	if s := Mask("abc", RedactPartial); s != "***" {
+		t.Errorf("partial mask=%s", s)
+	}
+	if s := Mask("secret", RedactFull); s != "******" {
+		t.Errorf("full mask=%s", s)
+	}
+	if s := Mask("secret", RedactHash); !strings.HasPrefix(s, "sha256:") || len(s) != 23 || s != Mask("secret", RedactHash) {
+		t.Errorf("hash mask=%s", s)
+	}
+	if _, err := NewRedactor([]RedactRule{{Mode: "full"}}); err == nil {
+		t.Error("empty rule should be invalid")
+	}
+	if _, err := NewRedactor([]RedactRule{{Field: "x", Mode: "unknown"}}); err == nil {
+		t.Error("unknown mode should be invalid")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	out := &bytes.Buffer{}
+	registerTestHandlerType(t, "test_redact", func(node *RawNode) (Handler, error) {
+		return &testSinkHandler{Level: zapcore.DebugLevel, out: out}, nil
+	})
+	YamlInit([]byte(`logging:
+  redact:
+    handler:
+      - typ: test_redact
+    redact:
+      - field: "password|token"
+      - field: "email"
+        mode: hash
+      - value: "1[3-9][0-9]{9}"
+        mode: partial`))
+	l := L("redact").With(zap.String("token", "abcdef"))
+	l.Info("call 13812345678",
+		zap.String("password", "p@ss"),
+		zap.String("note", "phone 13812345678"),
+		zap.Error(errors.New("user 13812345678 not found")),
+		zap.Int("password_len", 4),
+	)
+	L("redact").Sugar().Infow("payload", "body", map[string]interface{}{
+		"user":  map[string]interface{}{"email": "a@b.com", "phone": "13812345678"},
+		"items": []interface{}{map[string]interface{}{"token": "xyz"}},
+	})
+	res := out.String()
+	for _, secret := range []string{"abcdef", "p@ss", "13812345678", "a@b.com", "xyz"} {
+		if strings.Contains(res, secret) {
+			t.Errorf("%s not redacted: %s", secret, res)
+		}
+	}
+	for _, want := range []string{`"token":"******"`, `"password":"******"`, "13*******78", `"email":"sha256:`, `"password_len":4`} {
+		if !strings.Contains(res, want) {
+			t.Errorf("%s not found: %s", want, res)
+		}
+	}
+}
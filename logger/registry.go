@@ -0,0 +1,373 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v2"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 14:30
+// @File   : registry.go
+// @Project: utils/logger
+// ==========================
+
+// RawNode 某个 handler 的原始 yaml 配置.
+type RawNode struct {
+	Module string // 所属 module 名称
+	Index  int    // 在 module.handler 中的下标
+	Typ    string
+
+	node yaml.MapSlice
+}
+
+// Decode 把原始配置解析到 v, 用法同 yaml.Unmarshal.
+func (n *RawNode) Decode(v interface{}) error {
+	data, err := yaml.Marshal(n.node)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, v)
+}
+
+// HandlerFactory 根据原始配置创建 handler. 返回的 error 会附带 module 和 handler 信息后 panic.
+type HandlerFactory func(node *RawNode) (Handler, error)
+
+var (
+	handlerTypesMu sync.RWMutex
+	handlerTypes   = map[string]HandlerFactory{}
+)
+
+// RegisterHandlerType 注册 handler 类型, 名称即配置中的 typ, 重复注册会 panic.
+// 需要在 YamlInit 之前调用, 一般放在 sink 所在包的 init 中.
+func RegisterHandlerType(name string, factory HandlerFactory) {
+	handlerTypesMu.Lock()
+	defer handlerTypesMu.Unlock()
+	if _, ok := handlerTypes[name]; ok {
+		panic("handler typ " + name + " 重复注册")
+	}
+	handlerTypes[name] = factory
+}
+
+// HandlerTypes 返回已注册的 handler 类型.
+func HandlerTypes() []string {
+	handlerTypesMu.RLock()
+	defer handlerTypesMu.RUnlock()
+	res := make([]string, 0, len(handlerTypes))
+	for name := range handlerTypes {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func buildHandler(module string, index int, cfg *HandlerConfig) (Handler, error) {
+	handlerTypesMu.RLock()
+	factory, ok := handlerTypes[cfg.Typ]
+	handlerTypesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("invalid handler typ: %q (module %s, handler[%d]), support: %s", cfg.Typ, module, index, strings.Join(HandlerTypes(), ", "))
+	}
+	node, err := cfg.node()
+	if err != nil {
+		return nil, fmt.Errorf("module %s, handler[%d](%s): %v", module, index, cfg.Typ, err)
+	}
+	raw := &RawNode{Module: module, Index: index, Typ: cfg.Typ, node: node}
+	h, err := factory(raw)
+	if err != nil {
+		return nil, fmt.Errorf("module %s, handler[%d](%s): %v", module, index, cfg.Typ, err)
+	}
+	if h == nil {
+		return nil, fmt.Errorf("module %s, handler[%d](%s): factory returned nil handler", module, index, cfg.Typ)
+	}
+	wraps, err := commonWraps(raw)
+	if err != nil {
+		return nil, fmt.Errorf("module %s, handler[%d](%s): %v", module, index, cfg.Typ, err)
+	}
+	if len(wraps) > 0 {
+		h = &wrappedHandler{Handler: h, wraps: wraps}
+	}
+	return h, nil
+}
+
+// commonWraps 解析所有类型通用的 handler 配置.
+func commonWraps(node *RawNode) ([]func(zapcore.Core) zapcore.Core, error) {
+	var common struct {
+		Sampling *SamplingConfig `yaml:"sampling"`
+		Filter   *FilterConfig   `yaml:"filter"`
+	}
+	if err := node.Decode(&common); err != nil {
+		return nil, err
+	}
+	wraps := make([]func(zapcore.Core) zapcore.Core, 0)
+	if common.Sampling != nil {
+		wrap, err := common.Sampling.samplerWrap(node.Module)
+		if err != nil {
+			return nil, err
+		}
+		wraps = append(wraps, wrap)
+	}
+	if common.Filter != nil {
+		// 在采样之外, 被过滤的日志不计入采样.
+		wrap, err := common.Filter.filterWrap()
+		if err != nil {
+			return nil, err
+		}
+		wraps = append(wraps, wrap)
+	}
+	return wraps, nil
+}
+
+// ParseLevel 解析日志等级, 供自定义 factory 使用.
+func ParseLevel(level string) (zapcore.Level, error) {
+	l, ok := LogLevelMap[level]
+	if !ok {
+		return l, errors.New("level invalid: " + level)
+	}
+	return l, nil
+}
+
+// FormatEncoder 返回日志格式对应的 encoder, 除 json, logfmt 外均为 console.
+func FormatEncoder(format string) zapcore.Encoder {
+	switch format {
+	case "json":
+		return JsonFormatter
+	case "logfmt":
+		return LogfmtFormatter
+	default:
+		return ConsoleFormatter
+	}
+}
+
+// LayoutFor 返回切割间隔对应的历史文件后缀格式.
+func LayoutFor(du time.Duration) string {
+	if du >= time.Hour*24 {
+		return "2006-01-02"
+	} else if du >= time.Hour {
+		return "2006-01-02_15"
+	} else if du >= time.Minute {
+		return "2006-01-02_15_04"
+	}
+	return "2006-01-02_15_04_05"
+}
+
+func init() {
+	RegisterHandlerType("file", fileFactory)
+	RegisterHandlerType("rotate_file", rotateFileFactory)
+	RegisterHandlerType("email", emailFactory)
+	RegisterHandlerType("syslog", syslogFactory)
+	RegisterHandlerType("tcp", netFactory)
+	RegisterHandlerType("udp", netFactory)
+	RegisterHandlerType("http", httpFactory)
+	RegisterHandlerType("memory", memoryFactory)
+}
+
+// decodeBuiltin 解析内置 handler 的通用配置.
+func decodeBuiltin(node *RawNode) (*HandlerConfig, zapcore.Level, zapcore.Encoder, error) {
+	var cfg HandlerConfig
+	if err := node.Decode(&cfg); err != nil {
+		return nil, 0, nil, err
+	}
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	format, err := NewEncoder(cfg.Format, cfg.Encoder)
+	return &cfg, level, format, err
+}
+
+func fileFactory(node *RawNode) (Handler, error) {
+	cfg, level, format, err := decodeBuiltin(node)
+	if err != nil {
+		return nil, err
+	}
+	buffer, err := cfg.BufferOption()
+	if err != nil {
+		return nil, err
+	}
+	check, err := cfg.ReopenCheckDuration()
+	if err != nil {
+		return nil, err
+	}
+	return &FileHandler{
+		Filename: cfg.Filename,
+		Level:    level,
+		Format:   format,
+		Buffer:   buffer,
+
+		Reopen:      cfg.Reopen,
+		ReopenCheck: check,
+	}, nil
+}
+
+func rotateFileFactory(node *RawNode) (Handler, error) {
+	cfg, level, format, err := decodeBuiltin(node)
+	if err != nil {
+		return nil, err
+	}
+	du, err := time.ParseDuration(cfg.Duration)
+	if err != nil {
+		return nil, errors.New("invalid duration:" + cfg.Duration)
+	}
+	buffer, err := cfg.BufferOption()
+	if err != nil {
+		return nil, err
+	}
+	check, err := cfg.ReopenCheckDuration()
+	if err != nil {
+		return nil, err
+	}
+	hooks, err := buildRotateHooks(node.Module, cfg.Hooks)
+	if err != nil {
+		return nil, err
+	}
+	retry := 3
+	if _, ok := mapGet(node.node, "hook_retry"); ok {
+		retry = cfg.HookRetry
+	}
+	if retry < 0 {
+		return nil, fmt.Errorf("invalid hook_retry: %d", retry)
+	}
+	backoff, err := cfg.ParseDuration("hook_backoff", cfg.HookBackoff)
+	if err != nil {
+		return nil, err
+	}
+	retention, err := cfg.Retention.Policy(cfg.Replica)
+	if err != nil {
+		return nil, err
+	}
+	return &RotateHandler{
+		Filename: cfg.Filename,
+		Layout:   LayoutFor(du),
+		Duration: du,
+		Replica:  cfg.Replica,
+		Format:   format,
+		Level:    level,
+		Buffer:   buffer,
+
+		Reopen:      cfg.Reopen,
+		ReopenCheck: check,
+		Shared:      cfg.Shared,
+		Hooks:       hooks,
+		HookRetry:   retry,
+		HookBackoff: backoff,
+		Retention:   retention,
+	}, nil
+}
+
+func emailFactory(node *RawNode) (Handler, error) {
+	cfg, level, format, err := decodeBuiltin(node)
+	if err != nil {
+		return nil, err
+	}
+	subjectL := make([]string, 0)
+	for _, item := range cfg.StrField {
+		value := item.GetValue()
+		if value != "" {
+			subjectL = append(subjectL, item.Key+"="+value)
+		}
+	}
+	interval, err := cfg.ParseDuration("min_interval", cfg.MinInterval)
+	if err != nil {
+		return nil, err
+	}
+	return &EmailHandler{
+		Level:       level,
+		Format:      format,
+		Subject:     strings.Join(subjectL, ";"),
+		MinInterval: interval,
+		Module:      node.Module,
+	}, nil
+}
+
+func syslogFactory(node *RawNode) (Handler, error) {
+	cfg, level, format, err := decodeBuiltin(node)
+	if err != nil {
+		return nil, err
+	}
+	facility := SyslogFacilityMap["user"]
+	if cfg.Facility != "" {
+		var ok bool
+		facility, ok = SyslogFacilityMap[cfg.Facility]
+		if !ok {
+			return nil, errors.New("invalid syslog facility: " + cfg.Facility)
+		}
+	}
+	network := cfg.Network
+	if network == "" {
+		network = "unixgram"
+	}
+	timeout, err := cfg.ParseDuration("timeout", cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogHandler{
+		Network:  network,
+		Address:  cfg.Address,
+		Facility: facility,
+		AppName:  cfg.AppName,
+		Timeout:  timeout,
+		Level:    level,
+		Format:   format,
+	}, nil
+}
+
+func netFactory(node *RawNode) (Handler, error) {
+	cfg, level, format, err := decodeBuiltin(node)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := cfg.ParseDuration("timeout", cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	buffer, err := cfg.BufferOption()
+	if err != nil {
+		return nil, err
+	}
+	return &NetHandler{
+		Network: cfg.Typ,
+		Address: cfg.Address,
+		Timeout: timeout,
+		Level:   level,
+		Format:  format,
+		Buffer:  buffer,
+	}, nil
+}
+
+func httpFactory(node *RawNode) (Handler, error) {
+	cfg, level, format, err := decodeBuiltin(node)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Style {
+	case "", HTTPStyleNDJSON, HTTPStyleESBulk:
+	default:
+		return nil, errors.New("invalid http style: " + cfg.Style)
+	}
+	interval, err := cfg.ParseDuration("flush_interval", cfg.FlushInterval)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := cfg.ParseDuration("timeout", cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &HTTPHandler{
+		URL:           cfg.URL,
+		Headers:       cfg.Headers,
+		Style:         cfg.Style,
+		BatchSize:     cfg.BatchSize,
+		FlushInterval: interval,
+		Timeout:       timeout,
+		Level:         level,
+		Format:        format,
+	}, nil
+}
@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type testSinkHandler struct {
+	Brokers []string
+	Topic   string
+	Level   zapcore.Level
+	out     *bytes.Buffer
+}
+
+func (h *testSinkHandler) BuildWriter() (io.Writer, error) {
+	return h.out, nil
+}
+
+func (h *testSinkHandler) GetLevel() zapcore.Level {
+	return h.Level
+}
+
+func (h *testSinkHandler) GetFormat() zapcore.Encoder {
+	return JsonFormatter
+}
+
+// registerTestHandlerType 注册测试用的 handler 类型, 测试结束时注销, 以便 go test -count 多次运行.
+func registerTestHandlerType(t *testing.T, name string, factory HandlerFactory) {
+	t.Helper()
+	RegisterHandlerType(name, factory)
+	t.Cleanup(func() {
+		handlerTypesMu.Lock()
+		delete(handlerTypes, name)
+		handlerTypesMu.Unlock()
+	})
+}
+
+func TestRegisterHandlerType(t *testing.T) {
+	out := &bytes.Buffer{}
+	var got *testSinkHandler
+	registerTestHandlerType(t, "test_sink", func(node *RawNode) (Handler, error) {
+		var cfg struct {
+			Address []string `yaml:"address"` // 与内置字段类型不同
+			Topic   string   `yaml:"topic"`
+			Level   string   `yaml:"level"`
+		}
+		if err := node.Decode(&cfg); err != nil {
+			return nil, err
+		}
+		level, err := ParseLevel(cfg.Level)
+		if err != nil {
+			return nil, err
+		}
+		got = &testSinkHandler{Brokers: cfg.Address, Topic: cfg.Topic, Level: level, out: out}
+		return got, nil
+	})
+	YamlInit([]byte(`logging:
+  registry:
+    handler:
+      - typ: test_sink
+        address: ["127.0.0.1:9092", "127.0.0.2:9092"]
+        topic: logs
+        level: info`))
+	if got == nil || len(got.Brokers) != 2 || got.Topic != "logs" {
+		t.Fatalf("factory got %+v", got)
+	}
+	L("registry").Debug("skip")
+	L("registry").Info("hello")
+	if !bytes.Contains(out.Bytes(), []byte(`"msg_":"hello"`)) || bytes.Contains(out.Bytes(), []byte("skip")) {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
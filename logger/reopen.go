@@ -0,0 +1,244 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 11:05
+// @File   : reopen.go
+// @Project: utils/logger
+// ==========================
+
+// 配合外部的 logrotate 使用: 文件被改名/删除(create 模式)或被截断(copytruncate 模式)后, 重新打开文件.
+// 触发方式:
+//   1. 收到 SIGHUP/SIGUSR1 信号(配置了 reopen 的 handler 初始化时自动监听);
+//   2. 主动调用 logger.Reopen();
+//   3. 定时检查(reopen_check), 发现路径指向的 inode 变化, 或者文件大小变小.
+
+// Reopener 可以重新打开底层文件的 writer.
+type Reopener interface {
+	Reopen() error
+}
+
+// externalChecker 可以检测文件是否被外部切割.
+type externalChecker interface {
+	CheckExternal() (bool, error)
+}
+
+var (
+	reopenMu         sync.Mutex
+	reopeners        []Reopener
+	reopenSignalOnce sync.Once
+)
+
+// registerReopener 注册 Reopener. check > 0 时, 按该间隔检测文件是否被外部切割.
+// 返回的函数用于注销并停止检测, 在 handler 关闭时调用.
+func registerReopener(r Reopener, check time.Duration) (unregister func()) {
+	reopenMu.Lock()
+	reopeners = append(reopeners, r)
+	reopenMu.Unlock()
+	reopenSignalOnce.Do(func() {
+		ReopenOnSignal()
+	})
+	done := make(chan struct{})
+	if c, ok := r.(externalChecker); ok && check > 0 {
+		go func() {
+			ticker := time.NewTicker(check)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+				case <-done:
+					return
+				}
+				if _, err := c.CheckExternal(); err != nil {
+					fmt.Println(time.Now().Format(time.RFC3339), syscall.Getpid(), "[reopen check]", err)
+				}
+			}
+		}()
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			reopenMu.Lock()
+			defer reopenMu.Unlock()
+			for i, item := range reopeners {
+				if item == r {
+					reopeners = append(reopeners[:i], reopeners[i+1:]...)
+					break
+				}
+			}
+		})
+	}
+}
+
+// Reopen 重新打开所有配置了 reopen 的文件.
+func Reopen() error {
+	reopenMu.Lock()
+	items := make([]Reopener, len(reopeners))
+	copy(items, reopeners)
+	reopenMu.Unlock()
+	errs := make([]string, 0)
+	for _, r := range items {
+		if err := r.Reopen(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("reopen: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ReopenOnSignal 收到信号时调用 Reopen, 默认监听 SIGHUP 和 SIGUSR1. 返回的函数用于停止监听.
+func ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP, syscall.SIGUSR1}
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				if err := Reopen(); err != nil {
+					fmt.Println(time.Now().Format(time.RFC3339), syscall.Getpid(), "[reopen]", sig, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// externallyChanged 判断 filename 是否已经不是 fp 打开的文件(被改名/删除), 或者被截断.
+// lastSize 为上一次检查后已知的文件大小(包括之后写入的字节数), 返回本次检查的大小.
+func externallyChanged(fp *os.File, filename string, lastSize int64) (bool, int64, error) {
+	if fp == nil {
+		return true, 0, nil
+	}
+	fs, err := os.Stat(filename)
+	if os.IsNotExist(err) {
+		return true, 0, nil
+	}
+	if err != nil {
+		return false, lastSize, err
+	}
+	cur, err := fp.Stat()
+	if err != nil {
+		return false, lastSize, err
+	}
+	if !os.SameFile(fs, cur) {
+		return true, 0, nil
+	}
+	if fs.Size() < lastSize {
+		return true, 0, nil
+	}
+	return false, fs.Size(), nil
+}
+
+// ReopenFile 支持重新打开的文件, 用于 FileHandler.
+type ReopenFile struct {
+	mu         sync.Mutex
+	filename   string
+	fp         *os.File
+	lastSize   int64
+	closed     bool
+	unregister func() // 由 registerReopener 返回, Close 时调用
+}
+
+func OpenReopenFile(filename string) (*ReopenFile, error) {
+	f := &ReopenFile{filename: filename}
+	if err := f.Reopen(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *ReopenFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, err := f.fp.Write(p)
+	f.lastSize += int64(n)
+	return n, err
+}
+
+func (f *ReopenFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.fp.Sync()
+}
+
+func (f *ReopenFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reopen()
+}
+
+func (f *ReopenFile) reopen() error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	fp, err := os.OpenFile(f.filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if f.fp != nil {
+		f.fp.Close()
+	}
+	f.fp = fp
+	f.lastSize = 0
+	if fs, err := fp.Stat(); err == nil {
+		f.lastSize = fs.Size()
+	}
+	return nil
+}
+
+// Close 注销 reopen 并关闭文件, 之后的写入返回错误.
+func (f *ReopenFile) Close() error {
+	if f.unregister != nil {
+		f.unregister()
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	return f.fp.Close()
+}
+
+// CheckExternal 文件被外部切割时重新打开, 返回是否执行了重新打开.
+func (f *ReopenFile) CheckExternal() (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return false, nil
+	}
+	changed, size, err := externallyChanged(f.fp, f.filename, f.lastSize)
+	if err != nil {
+		return false, err
+	}
+	if !changed {
+		f.lastSize = size
+		return false, nil
+	}
+	return true, f.reopen()
+}
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReopenFile_CheckExternal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reopen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "app.log")
+	f, err := OpenReopenFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("first\n"))
+	if changed, _ := f.CheckExternal(); changed {
+		t.Errorf("unchanged file reported as rotated")
+	}
+
+	// create 模式: 外部改名后重新打开.
+	if err := os.Rename(fn, fn+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := f.CheckExternal(); !changed || err != nil {
+		t.Errorf("rename not detected: %v, %v", changed, err)
+	}
+	f.Write([]byte("second\n"))
+	if data, _ := ioutil.ReadFile(fn); string(data) != "second\n" {
+		t.Errorf("after rename got %q", data)
+	}
+
+	// copytruncate 模式: 文件变小.
+	if err := os.Truncate(fn, 0); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := f.CheckExternal(); !changed || err != nil {
+		t.Errorf("truncate not detected: %v, %v", changed, err)
+	}
+
+	// 主动 Reopen.
+	os.Remove(fn)
+	if err := f.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("third\n"))
+	if data, _ := ioutil.ReadFile(fn); string(data) != "third\n" {
+		t.Errorf("after reopen got %q", data)
+	}
+}
+
+func TestReopenFile_Close(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "app.log")
+	f, err := OpenReopenFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.unregister = registerReopener(f, 5*time.Millisecond)
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reopenMu.Lock()
+	for _, r := range reopeners {
+		if r == f {
+			t.Error("closed file still registered")
+		}
+	}
+	reopenMu.Unlock()
+
+	// 关闭后不再检测和重新打开.
+	os.Remove(fn)
+	time.Sleep(30 * time.Millisecond)
+	if _, err := os.Stat(fn); !os.IsNotExist(err) {
+		t.Errorf("closed file reopened: %v", err)
+	}
+	if _, err := f.Write([]byte("x\n")); err == nil {
+		t.Error("write after close should fail")
+	}
+}
@@ -0,0 +1,304 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 21:40
+// @File   : retention.go
+// @Project: utils/logger
+// ==========================
+
+// 清理原因
+const (
+	RetentionMaxCount   = "max_count"
+	RetentionMaxAge     = "max_age"
+	RetentionMaxSize    = "max_size"
+	RetentionDirMaxSize = "dir_max_size"
+)
+
+// RetentionConfig yaml 中 rotate_file.retention 的配置.
+type RetentionConfig struct {
+	MaxCount   int    `yaml:"max_count"`    // 保留的历史文件数, 默认为 replica
+	MaxAge     string `yaml:"max_age"`      // 历史文件的最长保留时间(按文件名中的时间计算), 如 168h
+	MaxSize    string `yaml:"max_size"`     // 该文件所有历史文件的总大小上限, 如 1GB
+	DirMaxSize string `yaml:"dir_max_size"` // 所在目录下所有 rotate_file 历史文件的总大小上限
+}
+
+// RetentionPolicy 历史文件的保留策略, 为 0 的项不限制.
+type RetentionPolicy struct {
+	MaxCount   int
+	MaxAge     time.Duration
+	MaxSize    int64
+	DirMaxSize int64
+}
+
+// Policy 解析配置, replica 为默认的 max_count.
+func (c *RetentionConfig) Policy(replica int) (RetentionPolicy, error) {
+	p := RetentionPolicy{MaxCount: replica}
+	if c == nil {
+		return p, nil
+	}
+	if c.MaxCount < 0 {
+		return p, fmt.Errorf("invalid retention max_count: %d", c.MaxCount)
+	}
+	if c.MaxCount > 0 {
+		p.MaxCount = c.MaxCount
+	}
+	var err error
+	if c.MaxAge != "" {
+		if p.MaxAge, err = time.ParseDuration(c.MaxAge); err != nil || p.MaxAge < 0 {
+			return p, errors.New("invalid retention max_age: " + c.MaxAge)
+		}
+	}
+	if p.MaxSize, err = ParseSize(c.MaxSize); err != nil {
+		return p, errors.New("invalid retention max_size: " + c.MaxSize)
+	}
+	if p.DirMaxSize, err = ParseSize(c.DirMaxSize); err != nil {
+		return p, errors.New("invalid retention dir_max_size: " + c.DirMaxSize)
+	}
+	return p, nil
+}
+
+// ParseSize 解析大小, 如 1024, 512KB, 100MB, 1GB, 单位为 1024 进制.
+func ParseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+	unit := int64(1)
+	for _, item := range []struct {
+		suffix string
+		unit   int64
+	}{{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, item.suffix) {
+			s, unit = strings.TrimSpace(s[:len(s)-len(item.suffix)]), item.unit
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, errors.New("invalid size: " + s)
+	}
+	return n * unit, nil
+}
+
+// HistoryFile 切割出的历史文件.
+type HistoryFile struct {
+	Path string
+	Time time.Time // 文件名中的时间, 即周期的开始
+	Size int64
+}
+
+// RetentionAction 对某个历史文件的清理.
+type RetentionAction struct {
+	File     HistoryFile
+	Reason   string // max_count | max_age | max_size | dir_max_size
+	Deferred bool   // hook 未完成, 正在执行时在完成后删除, 否则保留
+	Err      error
+}
+
+// RetentionReport 某个 rotate_file 的清理结果.
+type RetentionReport struct {
+	Filename string
+	DryRun   bool // 为 true 时只计算, 不删除
+	Kept     []HistoryFile
+	Removed  []RetentionAction
+}
+
+// Retention 按策略清理某个 rotate_file 的历史文件.
+// 每次都重新扫描目录, 文件名按 filename.<layout> 解析, 不匹配的文件(如锁文件, 归档)不处理, 手动删除文件也不影响.
+type Retention struct {
+	Filename string
+	Layout   string
+	Duration time.Duration
+	Policy   RetentionPolicy
+
+	hooks *hookRunner
+}
+
+// Scan 返回所有历史文件, 按时间从早到晚.
+func (r *Retention) Scan() ([]HistoryFile, error) {
+	matches, err := filepath.Glob(r.Filename + ".*")
+	if err != nil {
+		return nil, err
+	}
+	res := make([]HistoryFile, 0, len(matches))
+	for _, path := range matches {
+		// 切割时按 UTC 时间命名(见 RotateFile.Rotate), 因此同样按 UTC 解析, 与 time.Local 无关.
+		t, err := time.ParseInLocation(r.Layout, path[len(r.Filename)+1:], time.UTC)
+		if err != nil {
+			continue
+		}
+		fs, err := os.Stat(path)
+		if err != nil || !fs.Mode().IsRegular() {
+			continue
+		}
+		res = append(res, HistoryFile{Path: path, Time: t, Size: fs.Size()})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Time.Before(res[j].Time) })
+	return res, nil
+}
+
+// plan 计算需要删除的文件, files 按时间从早到晚.
+func (r *Retention) plan(files []HistoryFile, now time.Time) (kept []HistoryFile, removed []RetentionAction) {
+	p := r.Policy
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+	for i, f := range files {
+		reason := ""
+		switch {
+		case p.MaxCount > 0 && len(files)-i > p.MaxCount:
+			reason = RetentionMaxCount
+		case p.MaxAge > 0 && now.Sub(f.Time.Add(r.Duration)) > p.MaxAge:
+			reason = RetentionMaxAge
+		case p.MaxSize > 0 && total > p.MaxSize:
+			reason = RetentionMaxSize
+		}
+		if reason == "" {
+			kept = append(kept, f)
+			continue
+		}
+		total -= f.Size
+		removed = append(removed, RetentionAction{File: f, Reason: reason})
+	}
+	return kept, removed
+}
+
+// remove 删除文件, hook 未完成时推迟到完成后删除.
+func (r *Retention) remove(a *RetentionAction, dryRun bool) {
+	if dryRun {
+		return
+	}
+	if !r.hooks.release(a.File.Path) {
+		a.Deferred = true
+		return
+	}
+	a.Err = removeHistory(a.File.Path)
+}
+
+// Apply 按策略清理, dryRun 为 true 时只返回将要删除的文件.
+func (r *Retention) Apply(dryRun bool) (RetentionReport, error) {
+	report := RetentionReport{Filename: r.Filename, DryRun: dryRun}
+	files, err := r.Scan()
+	if err != nil {
+		return report, err
+	}
+	report.Kept, report.Removed = r.plan(files, time.Now())
+	for i := range report.Removed {
+		r.remove(&report.Removed[i], dryRun)
+	}
+	return report, nil
+}
+
+var (
+	retentionsMu sync.Mutex
+	retentions   = map[string]*Retention{}
+)
+
+func registerRetention(r *Retention) {
+	retentionsMu.Lock()
+	defer retentionsMu.Unlock()
+	retentions[r.Filename] = r
+}
+
+// unregisterRetention 在 rotate_file 关闭时调用, 文件已被新的 Retention 注册时不删除.
+func unregisterRetention(r *Retention) {
+	retentionsMu.Lock()
+	defer retentionsMu.Unlock()
+	if r != nil && retentions[r.Filename] == r {
+		delete(retentions, r.Filename)
+	}
+}
+
+// RunRetention 对所有 rotate_file 执行清理, 之后按目录执行 dir_max_size, dryRun 为 true 时只返回将要删除的文件.
+// rotate_file 启动和每次切割后会自动执行, 一般只在需要预览(dryRun)或立即清理时调用.
+func RunRetention(dryRun bool) ([]RetentionReport, error) {
+	return runRetention(dryRun, nil, nil)
+}
+
+// runRetention match 为空时清理所有已注册的文件; extra 为尚未注册的 Retention(启动时).
+func runRetention(dryRun bool, match func(*Retention) bool, extra *Retention) ([]RetentionReport, error) {
+	retentionsMu.Lock()
+	list := make([]*Retention, 0, len(retentions)+1)
+	for _, r := range retentions {
+		if r != extra && (match == nil || match(r)) {
+			list = append(list, r)
+		}
+	}
+	retentionsMu.Unlock()
+	if extra != nil {
+		list = append(list, extra)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Filename < list[j].Filename })
+
+	reports := make([]RetentionReport, 0, len(list))
+	var errs []string
+	for _, r := range list {
+		report, err := r.Apply(dryRun)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", r.Filename, err))
+		}
+		reports = append(reports, report)
+	}
+	applyDirQuota(list, reports, dryRun)
+	if len(errs) > 0 {
+		return reports, errors.New(strings.Join(errs, "; "))
+	}
+	return reports, nil
+}
+
+// applyDirQuota 同一目录下的历史文件总大小超过 dir_max_size 时, 从最早的开始删除. reports 与 list 一一对应.
+func applyDirQuota(list []*Retention, reports []RetentionReport, dryRun bool) {
+	type item struct {
+		file  HistoryFile
+		owner int
+	}
+	dirs := map[string][]item{}
+	quota := map[string]int64{}
+	for i, r := range list {
+		dir := filepath.Dir(r.Filename)
+		if q := r.Policy.DirMaxSize; q > 0 && (quota[dir] == 0 || q < quota[dir]) {
+			quota[dir] = q
+		}
+		for _, f := range reports[i].Kept {
+			dirs[dir] = append(dirs[dir], item{file: f, owner: i})
+		}
+	}
+	for dir, q := range quota {
+		items := dirs[dir]
+		sort.SliceStable(items, func(i, j int) bool { return items[i].file.Time.Before(items[j].file.Time) })
+		var total int64
+		for _, it := range items {
+			total += it.file.Size
+		}
+		for _, it := range items {
+			if total <= q {
+				break
+			}
+			total -= it.file.Size
+			a := RetentionAction{File: it.file, Reason: RetentionDirMaxSize}
+			list[it.owner].remove(&a, dryRun)
+			report := &reports[it.owner]
+			report.Removed = append(report.Removed, a)
+			for k, f := range report.Kept {
+				if f.Path == it.file.Path {
+					report.Kept = append(report.Kept[:k], report.Kept[k+1:]...)
+					break
+				}
+			}
+		}
+	}
+}
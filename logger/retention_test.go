@@ -0,0 +1,168 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{"": 0, "1024": 1024, "10B": 10, "2KB": 2048, "1 MB": 1 << 20, "3gb": 3 << 30}
+	for s, want := range tests {
+		if got, err := ParseSize(s); err != nil || got != want {
+			t.Errorf("ParseSize(%q)=%v, %v, want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"x", "-1", "1.5GB"} {
+		if _, err := ParseSize(s); err == nil {
+			t.Errorf("ParseSize(%q) should fail", s)
+		}
+	}
+}
+
+// writeHistory 创建 filename 最近 n 天的历史文件, 每个 size 字节.
+func writeHistory(t *testing.T, filename string, n int, size int) []string {
+	var res []string
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	for i := n; i >= 1; i-- {
+		path := filename + "." + day.Add(-time.Duration(i)*24*time.Hour).Format("2006-01-02")
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, path)
+	}
+	return res
+}
+
+func reasons(report RetentionReport) map[string]string {
+	res := map[string]string{}
+	for _, a := range report.Removed {
+		res[filepath.Base(a.File.Path)] = a.Reason
+	}
+	return res
+}
+
+func TestRetention(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "access.log")
+	files := writeHistory(t, fn, 6, 100)
+	// 不匹配 layout 的文件不处理.
+	os.WriteFile(fn+".2026-10-19.gz", []byte("x"), 0644)
+	os.WriteFile(fn+".lock", []byte("x"), 0644)
+
+	r := &Retention{Filename: fn, Layout: "2006-01-02", Duration: 24 * time.Hour, Policy: RetentionPolicy{MaxCount: 5, MaxAge: 72 * time.Hour, MaxSize: 250}}
+	report, err := r.Apply(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := reasons(report)
+	// 6 个文件: 第 1 个超出 max_count, 第 2, 3 个超过 max_age(结束时间距今超过 72h), 剩余 300 字节超出 max_size, 删除第 4 个.
+	want := map[string]string{
+		filepath.Base(files[0]): RetentionMaxCount,
+		filepath.Base(files[1]): RetentionMaxAge,
+		filepath.Base(files[2]): RetentionMaxAge,
+		filepath.Base(files[3]): RetentionMaxSize,
+	}
+	if len(got) != len(want) || len(report.Kept) != 2 {
+		t.Fatalf("got %v, kept %v", got, report.Kept)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s reason=%s, want %s", k, got[k], v)
+		}
+	}
+	// dry run 不删除.
+	for _, f := range files {
+		if _, err := os.Stat(f); err != nil {
+			t.Fatal("dry run removed", f)
+		}
+	}
+	if _, err := r.Apply(false); err != nil {
+		t.Fatal(err)
+	}
+	left, _ := r.Scan()
+	if len(left) != 2 || left[1].Path != files[5] {
+		t.Errorf("left=%v", left)
+	}
+	if _, err := os.Stat(fn + ".lock"); err != nil {
+		t.Error("unrelated file removed")
+	}
+}
+
+func TestRetentionTimezone(t *testing.T) {
+	// 与 time.Local 无关: 切割按 UTC 命名, max_age 不会偏移时区差.
+	local := time.Local
+	time.Local = time.FixedZone("UTC+8", 8*3600)
+	defer func() { time.Local = local }()
+
+	fn := filepath.Join(t.TempDir(), "access.log")
+	layout := "2006-01-02T15"
+	start := time.Now().UTC().Round(time.Hour).Add(-time.Hour) // 与 RotateFile.Rotate 中的命名相同
+	os.WriteFile(fn+"."+start.Format(layout), []byte("x"), 0644)
+	r := &Retention{Filename: fn, Layout: layout, Duration: time.Hour, Policy: RetentionPolicy{MaxAge: 2 * time.Hour}}
+	files, _ := r.Scan()
+	if len(files) != 1 || !files[0].Time.Equal(start) {
+		t.Fatalf("files=%v, want start %v", files, start)
+	}
+	if report, _ := r.Apply(true); len(report.Removed) != 0 {
+		t.Errorf("removed=%+v", report.Removed)
+	}
+}
+
+func TestRetentionManualDelete(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "access.log")
+	files := writeHistory(t, fn, 4, 10)
+	w, err := rotateWriter(&RotateFile{filename: fn, replica: 3}, "2006-01-02", 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(files[0]); !os.IsNotExist(err) {
+		t.Error("oldest file should be removed on start")
+	}
+	// 手动删除文件后切割不会出错.
+	os.Remove(files[2])
+	w.Write([]byte("line\n"))
+	if err := w.Rotate("2006-01-02", 24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	left, _ := w.retention.Scan()
+	if len(left) != 3 {
+		t.Errorf("left=%v", left)
+	}
+}
+
+func TestDirQuota(t *testing.T) {
+	dir := t.TempDir()
+	access, errorLog := filepath.Join(dir, "access.log"), filepath.Join(dir, "error.log")
+	accessFiles := writeHistory(t, access, 3, 100)
+	errorFiles := writeHistory(t, errorLog, 3, 100)
+	a := &Retention{Filename: access, Layout: "2006-01-02", Duration: 24 * time.Hour, Policy: RetentionPolicy{DirMaxSize: 350}}
+	b := &Retention{Filename: errorLog, Layout: "2006-01-02", Duration: 24 * time.Hour}
+	registerRetention(a)
+	registerRetention(b)
+	defer func() {
+		retentionsMu.Lock()
+		delete(retentions, access)
+		delete(retentions, errorLog)
+		retentionsMu.Unlock()
+	}()
+	reports, err := runRetention(false, func(r *Retention) bool { return strings.HasPrefix(r.Filename, dir) }, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 共 600 字节, 删除最早的 3 个(两个文件的第一天, 以及 access 的第二天, 同一天按文件名排序).
+	if len(reports) != 2 || len(reports[0].Removed) != 2 || len(reports[1].Removed) != 1 {
+		t.Fatalf("reports=%+v", reports)
+	}
+	for _, f := range []string{accessFiles[0], accessFiles[1], errorFiles[0]} {
+		if _, err := os.Stat(f); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed", f)
+		}
+	}
+	if reports[0].Removed[0].Reason != RetentionDirMaxSize {
+		t.Errorf("reason=%s", reports[0].Removed[0].Reason)
+	}
+}
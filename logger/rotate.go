@@ -6,7 +6,7 @@ import (
 	"github.com/zero-miao/go-utils/email"
 	"os"
 	"path/filepath"
-	"sort"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -19,57 +19,19 @@ import (
 // @Project: aotu/logger
 // ==========================
 
-// head in, tail out.
-// when add item, head-1, and check tail equal to head.
-// example:
-//   length=3; 依次加入 1,2,3,4. 变化如下:
-//   items = [x, x, x], head=0, tail=0, size=0; // add 1
-//   items = [1, x, x], head=1, tail=0, size=1; // add 2
-//   items = [1, 2, x], head=2, tail=0, size=2; // add 3
-//   items = [1, 2, 3], head=0, tail=0, size=3; // add 4
-//   items = [4, 2, 3], head=1, tail=1, size=3; // add 4
-type Deque struct {
-	items []string
-	cap   int // 容量
-	size  int // 实际元素个数
-	head  int // 指向下一个要填入 item 的位置. 如果 size < cap, 说明在第一圈. 不会返回任何元素.
-	mu    sync.Mutex
-}
-
-func (d *Deque) String() string {
-	return fmt.Sprintf("Deque<size=%v, cap=%v>%v", d.size, d.cap, d.items)
-}
-
-func (d *Deque) Reset(cap int) {
-	d.items = make([]string, cap)
-	d.cap = cap
-	d.size = 0
-	d.head = 0
-}
-
-func (d *Deque) Add(item string) string {
-	d.mu.Lock()
-	defer d.mu.Unlock()
-	if d.size < d.cap {
-		d.items[d.head] = item
-		d.size++
-		d.head = (d.head + 1) % d.cap
-		return ""
-	} else {
-		temp := d.items[d.head]
-		d.items[d.head] = item
-		d.head = (d.head + 1) % d.cap
-		return temp
-	}
-}
-
 type RotateFile struct {
 	lock       sync.Mutex
 	filename   string
 	fp         *os.File
 	lastRotate time.Time // 上一次切割时间.
-	validFiles Deque     // 循环队列
-	replica    int       // 队列长度
+	replica    int       // 保留的历史文件数, retention 为空时使用
+	retention  *Retention
+	lastSize   int64 // 已知的文件大小, 用于检查外部切割
+	shared     bool  // 多进程共享, 见 SharedRotateWriter
+	hooks      *hookRunner
+	closed     bool
+	unregister func()        // 配置了 reopen 时由 registerReopener 返回, Close 时调用
+	done       chan struct{} // Close 时关闭, 停止定时切割
 }
 
 func (w *RotateFile) Reset(layout string, duration time.Duration) error {
@@ -90,39 +52,64 @@ func (w *RotateFile) Reset(layout string, duration time.Duration) error {
 		return err
 	}
 	w.fp = f
-	w.validFiles.Reset(w.replica)
-	fn := w.filename + ".*"
-	matches, err := filepath.Glob(fn)
+	if w.retention == nil {
+		w.retention = &Retention{Filename: w.filename, Policy: RetentionPolicy{MaxCount: w.replica}, hooks: w.hooks}
+	}
+	if layout == "" {
+		layout = "2006-01-02"
+		duration = time.Hour * 24
+	}
+	w.retention.Layout, w.retention.Duration = layout, duration
+	files, err := w.retention.Scan()
 	if err != nil {
 		return err
 	}
-	sort.Strings(matches) // 字典序从小到大(即日期从早到晚)
-	lastItem := ""
-	for _, item := range matches {
-		lastItem = item
-		ftd := w.validFiles.Add(item)
-		if ftd != "" {
-			err := os.Remove(ftd)
-			return err
-		}
+	if len(files) > 0 {
+		w.lastRotate = files[len(files)-1].Time.Add(duration)
 	}
-	if lastItem != "" && layout != "" {
-		lastItem = lastItem[len(w.filename)+1:]
-		t, err := time.Parse(layout, lastItem)
-		if err == nil {
-			w.lastRotate = t.Add(duration)
+	if !w.shared {
+		// 重启前 hook 没有完成的文件重新执行. shared 模式下无法确定其他进程是否正在执行, 不重新执行.
+		w.hooks.resume(w.filename, files, duration)
+	}
+	return w.retain()
+}
+
+// retain 按 retention 清理历史文件, 在切割后和启动时执行.
+func (w *RotateFile) retain() error {
+	reports, err := runRetention(false, func(r *Retention) bool {
+		return r == w.retention || filepath.Dir(r.Filename) == filepath.Dir(w.filename)
+	}, w.retention)
+	if err != nil {
+		return err
+	}
+	errs := make([]string, 0)
+	for _, report := range reports {
+		for _, a := range report.Removed {
+			if a.Err != nil {
+				errs = append(errs, fmt.Sprintf("%s(%s): %v", a.File.Path, a.Reason, a.Err))
+			}
 		}
 	}
+	if len(errs) > 0 {
+		return errors.New("[retention] " + strings.Join(errs, "; "))
+	}
 	return nil
 }
 
 func (w *RotateFile) String() string {
-	return fmt.Sprintf("fn=%s, last_rotate=%v, history=%v", w.filename, w.lastRotate.Format(time.RFC3339), w.validFiles.String())
+	if w.retention == nil {
+		return fmt.Sprintf("fn=%s, last_rotate=%v", w.filename, w.lastRotate.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("fn=%s, last_rotate=%v, retention=%+v", w.filename, w.lastRotate.Format(time.RFC3339), w.retention.Policy)
 }
 
 // 考虑两个协程同时切割
 func (w *RotateFile) Rotate(layout string, duration time.Duration) error {
 	w.lock.Lock()
+	if w.closed {
+		w.lock.Unlock()
+		return nil
+	}
 	// 退出时, 重置 w.fp
 	defer func() {
 		// Close existing file if open
@@ -141,6 +128,7 @@ func (w *RotateFile) Rotate(layout string, duration time.Duration) error {
 		f, err := os.OpenFile(w.filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
 		if err == nil {
 			w.fp = f
+			w.lastSize = 0
 		} else {
 			errs = append(errs, fmt.Sprintf("[OpenFile] err=%v; w=%v", err, w))
 		}
@@ -154,6 +142,9 @@ func (w *RotateFile) Rotate(layout string, duration time.Duration) error {
 		layout = "2006-01-02"
 		duration = time.Hour * 24
 	}
+	if w.shared {
+		return w.rotateShared(layout, duration)
+	}
 
 	now := time.Now()
 	if fs, err := os.Stat(w.filename); err == nil {
@@ -165,26 +156,8 @@ func (w *RotateFile) Rotate(layout string, duration time.Duration) error {
 			if err != nil {
 				return errors.New(fmt.Sprintf("[rename] err=%v; src=%s, target=%s, w=%v", err, w.filename, lastFile, w))
 			}
-			ftd := w.validFiles.Add(lastFile)
-			if ftd != "" {
-				err := os.Remove(ftd)
-				if err != nil {
-					w.validFiles.Reset(w.replica)
-					fn := w.filename + ".*"
-					matches, err := filepath.Glob(fn)
-					if err != nil {
-						return err
-					}
-					sort.Strings(matches) // 字典序从小到大(即日期从早到晚)
-					for _, item := range matches {
-						ftd := w.validFiles.Add(item)
-						if ftd != "" {
-							os.Remove(ftd)
-						}
-					}
-					return errors.New(fmt.Sprintf("[remove] 重置 validFiles=%v", w))
-				}
-			}
+			w.afterRotate(lastFile, duration)
+			return w.retain()
 		} else {
 			fmt.Println(now.Format(time.RFC3339), syscall.Getpid(), "file empty", w.filename)
 		}
@@ -194,14 +167,90 @@ func (w *RotateFile) Rotate(layout string, duration time.Duration) error {
 	return nil
 }
 
+// afterRotate 在后台对刚切割出的历史文件执行 hook.
+func (w *RotateFile) afterRotate(path string, duration time.Duration) {
+	if w.hooks != nil {
+		w.hooks.dispatch(RotatedFile{Path: path, Filename: w.filename, Start: w.lastRotate.Add(-1 * duration), End: w.lastRotate})
+	}
+}
+
+// Reopen 重新打开 filename, 用于外部 logrotate 切割之后.
+func (w *RotateFile) Reopen() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.reopen()
+}
+
+func (w *RotateFile) reopen() error {
+	if w.closed {
+		return os.ErrClosed
+	}
+	f, err := os.OpenFile(w.filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if w.fp != nil {
+		w.fp.Close()
+	}
+	w.fp = f
+	w.lastSize = 0
+	if fs, err := f.Stat(); err == nil {
+		w.lastSize = fs.Size()
+	}
+	return nil
+}
+
+// CheckExternal 文件被外部切割时重新打开, 返回是否执行了重新打开.
+func (w *RotateFile) CheckExternal() (bool, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.closed {
+		return false, nil
+	}
+	changed, size, err := externallyChanged(w.fp, w.filename, w.lastSize)
+	if err != nil {
+		return false, err
+	}
+	if !changed {
+		w.lastSize = size
+		return false, nil
+	}
+	return true, w.reopen()
+}
+
 func (w *RotateFile) Write(output []byte) (int, error) {
 	//fmt.Println("write file")
 	w.lock.Lock()
 	defer w.lock.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
 	size, err := w.fp.Write(output)
+	w.lastSize += int64(size)
 	return size, err
 }
 
+// Close 停止定时切割, 注销 reopen 和 retention 并关闭文件, 之后的写入返回错误.
+func (w *RotateFile) Close() error {
+	if w.unregister != nil {
+		w.unregister()
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.done != nil {
+		close(w.done)
+	}
+	unregisterRetention(w.retention)
+	if w.fp == nil {
+		return nil
+	}
+	return w.fp.Close()
+}
+
 var body = `
 now = %v, pid = %v
 
@@ -213,11 +262,16 @@ err = %v
 `
 
 func RotateWriter(filename string, layout string, duration time.Duration, replica int) (*RotateFile, error) {
-	w := &RotateFile{filename: filename, replica: replica}
+	return rotateWriter(&RotateFile{filename: filename, replica: replica}, layout, duration)
+}
+
+func rotateWriter(w *RotateFile, layout string, duration time.Duration) (*RotateFile, error) {
 	err := w.Reset(layout, duration)
 	if err != nil {
 		return nil, err
 	}
+	registerRetention(w.retention)
+	w.done = make(chan struct{})
 	go func(w *RotateFile, layout string, duration time.Duration) {
 		// 刚运行时, 发现有文件, 则认为应该接着往里写.
 		//err := w.Rotate(layout, duration)
@@ -225,15 +279,22 @@ func RotateWriter(filename string, layout string, duration time.Duration, replic
 		//	email.SendServerMail("rotate error", fmt.Sprintf(body, time.Now().Format(time.RFC3339), syscall.Getpid(), w, layout, duration, err), "text/plain")
 		//}
 		now := time.Now()
-		timeToSleep := GetMinMoreThan(now, duration).Sub(now)
-		time.Sleep(timeToSleep)
+		timer := time.NewTimer(GetMinMoreThan(now, duration).Sub(now))
+		defer timer.Stop()
 		for {
+			select {
+			case <-timer.C:
+			case <-w.done:
+				return
+			}
+			start := time.Now()
 			err := w.Rotate(layout, duration)
+			observeRotate(w.filename, start, err)
 			if err != nil {
 				//fmt.Println(syscall.Getpid(), err)
 				email.SendServerMail("rotate error", fmt.Sprintf(body, time.Now().Format(time.RFC3339), syscall.Getpid(), w, layout, duration, err), "text/plain")
 			}
-			time.Sleep(duration)
+			timer.Reset(duration)
 		}
 	}(w, layout, duration)
 	return w, nil
@@ -0,0 +1,176 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 15:20
+// @File   : sampling.go
+// @Project: utils/logger
+// ==========================
+
+// 抑制原因
+const (
+	SuppressSampling      = "sampling"       // handler.sampling 采样丢弃
+	SuppressEmailInterval = "email_interval" // email handler 的 min_interval 内重复的日志
+)
+
+// SamplingConfig yaml 中 handler.sampling 的配置, 对应 zapcore.NewSamplerWithOptions:
+// 每个 tick 内, 相同等级和内容的日志, 前 first 条全部输出, 之后每 thereafter 条输出一条.
+type SamplingConfig struct {
+	Tick       string `yaml:"tick"` // 默认 1s
+	First      int    `yaml:"first"`
+	Thereafter int    `yaml:"thereafter"`
+}
+
+// SuppressedHook 日志被抑制(未输出)时调用.
+type SuppressedHook func(module string, ent zapcore.Entry, reason string)
+
+var (
+	suppressedMu    sync.Mutex
+	suppressedHook  SuppressedHook
+	suppressedCount = map[string]map[string]uint64{}
+)
+
+// SetSuppressedHook 设置日志被抑制时的回调, 为 nil 时只计数.
+func SetSuppressedHook(hook SuppressedHook) {
+	suppressedMu.Lock()
+	defer suppressedMu.Unlock()
+	suppressedHook = hook
+}
+
+// Suppressed 返回各 module 按原因统计的被抑制日志条数.
+func Suppressed() map[string]map[string]uint64 {
+	suppressedMu.Lock()
+	defer suppressedMu.Unlock()
+	res := make(map[string]map[string]uint64, len(suppressedCount))
+	for module, counts := range suppressedCount {
+		res[module] = make(map[string]uint64, len(counts))
+		for reason, count := range counts {
+			res[module][reason] = count
+		}
+	}
+	return res
+}
+
+func suppressed(module string, ent zapcore.Entry, reason string) {
+	suppressedMu.Lock()
+	counts, ok := suppressedCount[module]
+	if !ok {
+		counts = map[string]uint64{}
+		suppressedCount[module] = counts
+	}
+	counts[reason]++
+	hook := suppressedHook
+	suppressedMu.Unlock()
+	if hook != nil {
+		hook(module, ent, reason)
+	}
+}
+
+// samplerWrap 解析 sampling 配置, 返回包装 core 的函数.
+func (c *SamplingConfig) samplerWrap(module string) (func(zapcore.Core) zapcore.Core, error) {
+	tick := time.Second
+	if c.Tick != "" {
+		du, err := time.ParseDuration(c.Tick)
+		if err != nil || du <= 0 {
+			return nil, errors.New("invalid sampling tick: " + c.Tick)
+		}
+		tick = du
+	}
+	if c.First < 0 || c.Thereafter < 0 {
+		return nil, errors.New("invalid sampling: first and thereafter must not be negative")
+	}
+	hook := zapcore.SamplerHook(func(ent zapcore.Entry, dec zapcore.SamplingDecision) {
+		if dec&zapcore.LogDropped != 0 {
+			suppressed(module, ent, SuppressSampling)
+		}
+	})
+	first, thereafter := c.First, c.Thereafter
+	return func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewSamplerWithOptions(core, tick, first, thereafter, hook)
+	}, nil
+}
+
+// intervalCore 相同内容(message)的日志在 interval 内只输出一次, 之后输出时附带期间被抑制的条数(suppressed_).
+type intervalCore struct {
+	zapcore.Core
+	module   string
+	interval time.Duration
+	state    *intervalState
+}
+
+// intervalMaxKeys intervalCore 最多记录的 message 数, 超过时先删除过期的, 仍然超过时删除最早的.
+const intervalMaxKeys = 1000
+
+type intervalState struct {
+	mu   sync.Mutex
+	last map[string]*intervalItem
+}
+
+type intervalItem struct {
+	at         time.Time
+	suppressed uint64
+}
+
+func newIntervalCore(core zapcore.Core, module string, interval time.Duration) zapcore.Core {
+	return &intervalCore{Core: core, module: module, interval: interval, state: &intervalState{last: map[string]*intervalItem{}}}
+}
+
+func (c *intervalCore) With(fields []zapcore.Field) zapcore.Core {
+	return &intervalCore{Core: c.Core.With(fields), module: c.module, interval: c.interval, state: c.state}
+}
+
+func (c *intervalCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *intervalCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	s := c.state
+	s.mu.Lock()
+	item, ok := s.last[ent.Message]
+	if ok && ent.Time.Sub(item.at) < c.interval {
+		item.suppressed++
+		s.mu.Unlock()
+		suppressed(c.module, ent, SuppressEmailInterval)
+		return nil
+	}
+	var count uint64
+	if ok {
+		count = item.suppressed
+	}
+	if !ok && len(s.last) >= intervalMaxKeys {
+		for msg, v := range s.last {
+			if ent.Time.Sub(v.at) >= c.interval {
+				delete(s.last, msg)
+			}
+		}
+		if len(s.last) >= intervalMaxKeys {
+			var oldest string
+			var at time.Time
+			first := true
+			for msg, v := range s.last {
+				if first || v.at.Before(at) {
+					oldest, at, first = msg, v.at, false
+				}
+			}
+			delete(s.last, oldest)
+		}
+	}
+	s.last[ent.Message] = &intervalItem{at: ent.Time}
+	s.mu.Unlock()
+	if count > 0 {
+		fields = append(fields[:len(fields):len(fields)], zap.Uint64("suppressed_", count))
+	}
+	return c.Core.Write(ent, fields)
+}
@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSampling(t *testing.T) {
+	out := &bytes.Buffer{}
+	registerTestHandlerType(t, "test_sampling", func(node *RawNode) (Handler, error) {
+		return &testSinkHandler{Level: zapcore.DebugLevel, out: out}, nil
+	})
+	var mu sync.Mutex
+	hooked := 0
+	SetSuppressedHook(func(module string, ent zapcore.Entry, reason string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if module == "sampling" && reason == SuppressSampling {
+			hooked++
+		}
+	})
+	defer SetSuppressedHook(nil)
+	// Suppressed 为进程内累计值, 只比较本次测试的增量.
+	before := Suppressed()["sampling"][SuppressSampling]
+	YamlInit([]byte(`logging:
+  sampling:
+    handler:
+      - typ: test_sampling
+        sampling:
+          tick: 1m
+          first: 3
+          thereafter: 5`))
+	for i := 0; i < 13; i++ {
+		L("sampling").Info("hot loop")
+	}
+	// 前 3 条, 之后第 5, 10 条: 3+2=5
+	if n := strings.Count(out.String(), "hot loop"); n != 5 {
+		t.Errorf("sampled count=%v, want 5", n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if hooked != 8 || Suppressed()["sampling"][SuppressSampling]-before != 8 {
+		t.Errorf("hooked=%v, suppressed=%v, want 8", hooked, Suppressed())
+	}
+}
+
+func TestIntervalCore(t *testing.T) {
+	out := &bytes.Buffer{}
+	enc := zapcore.NewJSONEncoder(consoleEncoderConfig)
+	core := newIntervalCore(zapcore.NewCore(enc, zapcore.AddSync(out), zapcore.DebugLevel), "interval", time.Minute)
+	l := zap.New(core)
+	for i := 0; i < 3; i++ {
+		l.Error("db down")
+	}
+	l.Error("other")
+	if n := strings.Count(out.String(), "db down"); n != 1 {
+		t.Errorf("db down count=%v, want 1", n)
+	}
+	// 超过间隔后输出, 并附带抑制条数.
+	ce := core.Check(zapcore.Entry{Level: zapcore.ErrorLevel, Message: "db down", Time: time.Now().Add(time.Hour)}, nil)
+	ce.Write()
+	if !strings.Contains(out.String(), `"suppressed_":2`) {
+		t.Errorf("suppressed_ not found: %s", out.String())
+	}
+
+	// 全部未过期时删除最早的, 不超过 intervalMaxKeys.
+	state := core.(*intervalCore).state
+	now := time.Now()
+	for i := 0; i < intervalMaxKeys+10; i++ {
+		core.Write(zapcore.Entry{Level: zapcore.ErrorLevel, Message: "msg" + strconv.Itoa(i), Time: now.Add(time.Duration(i) * time.Millisecond)}, nil)
+	}
+	if n := len(state.last); n != intervalMaxKeys {
+		t.Errorf("keys=%v, want %v", n, intervalMaxKeys)
+	}
+	if _, ok := state.last["msg0"]; ok {
+		t.Error("oldest key not evicted")
+	}
+	if _, ok := state.last["msg"+strconv.Itoa(intervalMaxKeys+9)]; !ok {
+		t.Error("newest key evicted")
+	}
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 20:10
+// @File   : shared.go
+// @Project: utils/logger
+// ==========================
+
+// 多进程(如 prefork)共享同一个 rotate_file 时, 各进程的切割通过 sidecar 文件锁协调:
+// 先拿到锁的进程负责改名和清理历史文件, 其他进程发现本周期的历史文件已经存在, 只重新打开文件.
+
+// sharedLockPath 返回 filename 对应的锁文件, 以 . 开头, 不会被 filename.* 匹配为历史文件.
+func sharedLockPath(filename string) string {
+	return filepath.Join(filepath.Dir(filename), "."+filepath.Base(filename)+".lock")
+}
+
+// SharedRotateWriter 同 RotateWriter, 用于多个进程写同一个文件.
+func SharedRotateWriter(filename string, layout string, duration time.Duration, replica int) (*RotateFile, error) {
+	return rotateWriter(&RotateFile{filename: filename, replica: replica, shared: true}, layout, duration)
+}
+
+// rotateShared 在持有 w.lock 时调用, 返回后由 Rotate 重新打开文件.
+func (w *RotateFile) rotateShared(layout string, duration time.Duration) error {
+	lock, err := lockFile(sharedLockPath(w.filename))
+	if err != nil {
+		return fmt.Errorf("[lock] err=%v; w=%v", err, w)
+	}
+	defer lock.Unlock()
+
+	now := time.Now()
+	w.lastRotate = now.UTC().Round(duration)
+	lastFile := w.filename + "." + w.lastRotate.Add(-1*duration).Format(layout)
+	if _, err := os.Stat(lastFile); err == nil {
+		// 其他进程已经完成本周期的切割.
+		return nil
+	}
+	fs, err := os.Stat(w.filename)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("[os.Stat] err=%v; w=%v", err, w)
+	}
+	if fs.Size() == 0 {
+		fmt.Println(now.Format(time.RFC3339), syscall.Getpid(), "file empty", w.filename)
+		return nil
+	}
+	fmt.Printf("%s, %v [rotate shared file] w=%v, size=%v\n", now.Format(time.RFC3339), syscall.Getpid(), w, fs.Size())
+	if err := os.Rename(w.filename, lastFile); err != nil {
+		return fmt.Errorf("[rename] err=%v; src=%s, target=%s, w=%v", err, w.filename, lastFile, w)
+	}
+	w.afterRotate(lastFile, duration)
+	return w.retain()
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const sharedHelperEnv = "LOGGER_SHARED_ROTATE_FILE"
+
+// TestSharedRotateHelper 由 TestSharedRotate 以子进程方式运行.
+func TestSharedRotateHelper(t *testing.T) {
+	fn := os.Getenv(sharedHelperEnv)
+	if fn == "" {
+		t.Skip("helper process")
+	}
+	id := os.Getenv("LOGGER_SHARED_ID")
+	w, err := SharedRotateWriter(fn, "2006-01-02_15_04_05", time.Second, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for deadline := time.Now().Add(2500 * time.Millisecond); time.Now().Before(deadline); n++ {
+		if _, err := w.Write([]byte(fmt.Sprintf("%s %d\n", id, n))); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	fmt.Printf("written=%d\n", n)
+}
+
+func TestSharedRotate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("multi-process test")
+	}
+	fn := filepath.Join(t.TempDir(), "shared.log")
+	const procs = 4
+	written := make([]int, procs)
+	var wg sync.WaitGroup
+	for i := 0; i < procs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=^TestSharedRotateHelper$", "-test.count=1", "-test.v")
+			cmd.Env = append(os.Environ(), sharedHelperEnv+"="+fn, "LOGGER_SHARED_ID="+strconv.Itoa(i))
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Errorf("helper %d: %v\n%s", i, err, out)
+				return
+			}
+			for _, line := range strings.Split(string(out), "\n") {
+				if strings.HasPrefix(line, "written=") {
+					written[i], _ = strconv.Atoi(line[len("written="):])
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	files, _ := filepath.Glob(fn + "*")
+	if len(files) < 3 {
+		t.Errorf("expect at least 2 rotated files, got %v", files)
+	}
+	got := make([]int, procs)
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var id, seq int
+			if _, err := fmt.Sscanf(scanner.Text(), "%d %d", &id, &seq); err == nil && id < procs {
+				got[id]++
+			}
+		}
+		f.Close()
+	}
+	for i := 0; i < procs; i++ {
+		if written[i] == 0 || got[i] != written[i] {
+			t.Errorf("helper %d: written=%d, found=%d", i, written[i], got[i])
+		}
+	}
+	if _, err := os.Stat(sharedLockPath(fn)); err != nil {
+		t.Errorf("lock file: %v", err)
+	}
+}
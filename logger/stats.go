@@ -0,0 +1,377 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-19 20:40
+// @File   : stats.go
+// @Project: utils/logger
+// ==========================
+
+// LatencyBuckets 耗时直方图的上界(秒).
+var LatencyBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// histogram 线程安全的耗时直方图.
+type histogram struct {
+	counts []uint64 // 与 LatencyBuckets 对应, 最后一个为 +Inf
+	count  uint64
+	sum    uint64 // 纳秒
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]uint64, len(LatencyBuckets)+1)}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	s := d.Seconds()
+	i := sort.SearchFloat64s(LatencyBuckets, s)
+	atomic.AddUint64(&h.counts[i], 1)
+	atomic.AddUint64(&h.count, 1)
+	atomic.AddUint64(&h.sum, uint64(d))
+}
+
+// Histogram 耗时直方图的快照.
+type Histogram struct {
+	Buckets []float64 // 上界(秒), 同 LatencyBuckets
+	Counts  []uint64  // 小于等于对应上界的累计次数, 比 Buckets 多一项(+Inf)
+	Count   uint64
+	Sum     time.Duration
+}
+
+func (h *histogram) snapshot() Histogram {
+	res := Histogram{Buckets: LatencyBuckets, Counts: make([]uint64, len(h.counts))}
+	var total uint64
+	for i := range h.counts {
+		total += atomic.LoadUint64(&h.counts[i])
+		res.Counts[i] = total
+	}
+	res.Count = total
+	res.Sum = time.Duration(atomic.LoadUint64(&h.sum))
+	return res
+}
+
+// HandlerStats 某个 handler 的写入统计.
+type HandlerStats struct {
+	Module  string
+	Handler string // handler 的 name, 未配置时为 <typ>.<下标>
+	Entries uint64 // 写入的条数
+	Bytes   uint64
+	Errors  uint64
+	Latency Histogram // 单次写入的耗时, 可以看出写入是否阻塞
+}
+
+// RotateStats 某个 rotate_file 的切割统计.
+type RotateStats struct {
+	File      string
+	Rotations uint64
+	Errors    uint64
+	Duration  Histogram
+}
+
+// EmailStats 某个 module 的邮件报警统计.
+type EmailStats struct {
+	Module string
+	Sent   uint64
+	Failed uint64
+}
+
+// StatsSnapshot logger 自身的统计.
+type StatsSnapshot struct {
+	Handlers   []HandlerStats
+	Rotations  []RotateStats
+	Emails     []EmailStats
+	Dropped    map[string]uint64            // 同 DroppedEntries
+	Suppressed map[string]map[string]uint64 // 同 Suppressed
+}
+
+type handlerCounter struct {
+	entries, bytes, errors uint64
+	latency                *histogram
+}
+
+type rotateCounter struct {
+	rotations, errors uint64
+	duration          *histogram
+}
+
+type emailCounter struct {
+	sent, failed uint64
+}
+
+var (
+	statsMu         sync.Mutex
+	handlerCounters = map[[2]string]*handlerCounter{}
+	rotateCounters  = map[string]*rotateCounter{}
+	emailCounters   = map[string]*emailCounter{}
+)
+
+func getHandlerCounter(module, handler string) *handlerCounter {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	key := [2]string{module, handler}
+	c, ok := handlerCounters[key]
+	if !ok {
+		c = &handlerCounter{latency: newHistogram()}
+		handlerCounters[key] = c
+	}
+	return c
+}
+
+func getRotateCounter(file string) *rotateCounter {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	c, ok := rotateCounters[file]
+	if !ok {
+		c = &rotateCounter{duration: newHistogram()}
+		rotateCounters[file] = c
+	}
+	return c
+}
+
+func getEmailCounter(module string) *emailCounter {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	c, ok := emailCounters[module]
+	if !ok {
+		c = &emailCounter{}
+		emailCounters[module] = c
+	}
+	return c
+}
+
+func observeRotate(file string, start time.Time, err error) {
+	c := getRotateCounter(file)
+	atomic.AddUint64(&c.rotations, 1)
+	if err != nil {
+		atomic.AddUint64(&c.errors, 1)
+	}
+	c.duration.observe(time.Since(start))
+}
+
+func observeEmail(module string, err error) {
+	c := getEmailCounter(module)
+	if err != nil {
+		atomic.AddUint64(&c.failed, 1)
+	} else {
+		atomic.AddUint64(&c.sent, 1)
+	}
+}
+
+// meteredWriter 统计写入的条数, 字节数, 错误和耗时. 每次 Write 为一条日志.
+type meteredWriter struct {
+	w io.Writer
+	c *handlerCounter
+}
+
+func (m *meteredWriter) observe(start time.Time, n int, err error) {
+	atomic.AddUint64(&m.c.entries, 1)
+	atomic.AddUint64(&m.c.bytes, uint64(n))
+	if err != nil {
+		atomic.AddUint64(&m.c.errors, 1)
+	}
+	m.c.latency.observe(time.Since(start))
+}
+
+func (m *meteredWriter) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := m.w.Write(p)
+	m.observe(start, len(p), err)
+	return n, err
+}
+
+func (m *meteredWriter) Sync() error {
+	if s, ok := m.w.(zapcore.WriteSyncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+type meteredLevelWriter struct {
+	meteredWriter
+	lw LevelWriter
+}
+
+func (m *meteredLevelWriter) WriteLevel(level zapcore.Level, p []byte) (int, error) {
+	start := time.Now()
+	n, err := m.lw.WriteLevel(level, p)
+	m.observe(start, len(p), err)
+	return n, err
+}
+
+// meterWriter 包装 writer, 保留 LevelWriter 接口.
+func meterWriter(module, handler string, w io.Writer) io.Writer {
+	m := meteredWriter{w: w, c: getHandlerCounter(module, handler)}
+	if lw, ok := w.(LevelWriter); ok {
+		return &meteredLevelWriter{meteredWriter: m, lw: lw}
+	}
+	return &m
+}
+
+// Stats 返回 logger 自身统计的快照.
+func Stats() StatsSnapshot {
+	res := StatsSnapshot{Dropped: DroppedEntries(), Suppressed: Suppressed()}
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	for key, c := range handlerCounters {
+		res.Handlers = append(res.Handlers, HandlerStats{
+			Module:  key[0],
+			Handler: key[1],
+			Entries: atomic.LoadUint64(&c.entries),
+			Bytes:   atomic.LoadUint64(&c.bytes),
+			Errors:  atomic.LoadUint64(&c.errors),
+			Latency: c.latency.snapshot(),
+		})
+	}
+	sort.Slice(res.Handlers, func(i, j int) bool {
+		if res.Handlers[i].Module != res.Handlers[j].Module {
+			return res.Handlers[i].Module < res.Handlers[j].Module
+		}
+		return res.Handlers[i].Handler < res.Handlers[j].Handler
+	})
+	for file, c := range rotateCounters {
+		res.Rotations = append(res.Rotations, RotateStats{
+			File:      file,
+			Rotations: atomic.LoadUint64(&c.rotations),
+			Errors:    atomic.LoadUint64(&c.errors),
+			Duration:  c.duration.snapshot(),
+		})
+	}
+	sort.Slice(res.Rotations, func(i, j int) bool { return res.Rotations[i].File < res.Rotations[j].File })
+	for module, c := range emailCounters {
+		res.Emails = append(res.Emails, EmailStats{Module: module, Sent: atomic.LoadUint64(&c.sent), Failed: atomic.LoadUint64(&c.failed)})
+	}
+	sort.Slice(res.Emails, func(i, j int) bool { return res.Emails[i].Module < res.Emails[j].Module })
+	return res
+}
+
+// PrometheusHandler 以 prometheus 文本格式输出 Stats, 如 http.Handle("/metrics/logger", logger.PrometheusHandler()).
+func PrometheusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WritePrometheus(w, Stats())
+	})
+}
+
+// WritePrometheus 以 prometheus 文本格式输出统计.
+func WritePrometheus(w io.Writer, s StatsSnapshot) {
+	p := &promWriter{w: w}
+	p.header("logger_handler_entries_total", "counter", "Log entries written by handler.")
+	for _, h := range s.Handlers {
+		p.sample("logger_handler_entries_total", labels("module", h.Module, "handler", h.Handler), float64(h.Entries))
+	}
+	p.header("logger_handler_bytes_total", "counter", "Bytes written by handler.")
+	for _, h := range s.Handlers {
+		p.sample("logger_handler_bytes_total", labels("module", h.Module, "handler", h.Handler), float64(h.Bytes))
+	}
+	p.header("logger_handler_write_errors_total", "counter", "Failed writes by handler.")
+	for _, h := range s.Handlers {
+		p.sample("logger_handler_write_errors_total", labels("module", h.Module, "handler", h.Handler), float64(h.Errors))
+	}
+	p.header("logger_handler_write_seconds", "histogram", "Time spent in a single handler write.")
+	for _, h := range s.Handlers {
+		p.histogram("logger_handler_write_seconds", labels("module", h.Module, "handler", h.Handler), h.Latency)
+	}
+	p.header("logger_rotate_total", "counter", "Rotations of rotate_file.")
+	for _, r := range s.Rotations {
+		p.sample("logger_rotate_total", labels("file", r.File), float64(r.Rotations))
+	}
+	p.header("logger_rotate_errors_total", "counter", "Failed rotations of rotate_file.")
+	for _, r := range s.Rotations {
+		p.sample("logger_rotate_errors_total", labels("file", r.File), float64(r.Errors))
+	}
+	p.header("logger_rotate_seconds", "histogram", "Time spent rotating rotate_file.")
+	for _, r := range s.Rotations {
+		p.histogram("logger_rotate_seconds", labels("file", r.File), r.Duration)
+	}
+	p.header("logger_email_sent_total", "counter", "Alert emails sent.")
+	for _, e := range s.Emails {
+		p.sample("logger_email_sent_total", labels("module", e.Module), float64(e.Sent))
+	}
+	p.header("logger_email_failed_total", "counter", "Alert emails failed to send.")
+	for _, e := range s.Emails {
+		p.sample("logger_email_failed_total", labels("module", e.Module), float64(e.Failed))
+	}
+	p.header("logger_buffer_dropped_total", "counter", "Entries dropped by buffered writers.")
+	for _, file := range sortedKeys(s.Dropped) {
+		p.sample("logger_buffer_dropped_total", labels("file", file), float64(s.Dropped[file]))
+	}
+	p.header("logger_suppressed_total", "counter", "Entries suppressed by sampling or email min_interval.")
+	modules := make([]string, 0, len(s.Suppressed))
+	for module := range s.Suppressed {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	for _, module := range modules {
+		for _, reason := range sortedKeys(s.Suppressed[module]) {
+			p.sample("logger_suppressed_total", labels("module", module, "reason", reason), float64(s.Suppressed[module][reason]))
+		}
+	}
+}
+
+func sortedKeys(m map[string]uint64) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labels(kv ...string) string {
+	parts := make([]string, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		parts = append(parts, kv[i]+`="`+labelEscaper.Replace(kv[i+1])+`"`)
+	}
+	return strings.Join(parts, ",")
+}
+
+type promWriter struct {
+	w io.Writer
+}
+
+func (p *promWriter) header(name, typ, help string) {
+	fmt.Fprintf(p.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func (p *promWriter) sample(name, labels string, value float64) {
+	if labels != "" {
+		name += "{" + labels + "}"
+	}
+	fmt.Fprintf(p.w, "%s %s\n", name, promFloat(value))
+}
+
+func (p *promWriter) histogram(name, lbs string, h Histogram) {
+	sep := ""
+	if lbs != "" {
+		sep = ","
+	}
+	for i, le := range h.Buckets {
+		p.sample(name+"_bucket", lbs+sep+`le="`+promFloat(le)+`"`, float64(h.Counts[i]))
+	}
+	p.sample(name+"_bucket", lbs+sep+`le="+Inf"`, float64(h.Count))
+	p.sample(name+"_sum", lbs, h.Sum.Seconds())
+	p.sample(name+"_count", lbs, float64(h.Count))
+}
+
+func promFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	h := newHistogram()
+	h.observe(50 * time.Microsecond)
+	h.observe(3 * time.Millisecond)
+	h.observe(10 * time.Second)
+	s := h.snapshot()
+	// 0.0001, 0.0005, 0.001, 0.005 ...
+	if s.Counts[0] != 1 || s.Counts[2] != 1 || s.Counts[3] != 2 || s.Counts[len(s.Counts)-1] != 3 || s.Count != 3 {
+		t.Errorf("unexpected histogram %+v", s)
+	}
+}
+
+func TestStats(t *testing.T) {
+	// 计数为进程内累计值, 测试结束后删除, 重复运行(-count)时重新计数.
+	t.Cleanup(func() {
+		statsMu.Lock()
+		defer statsMu.Unlock()
+		delete(handlerCounters, [2]string{"stats", "recent"})
+		delete(handlerCounters, [2]string{"stats", "memory.1"})
+		delete(rotateCounters, "/tmp/stats.log")
+		delete(emailCounters, "stats")
+	})
+	YamlInit([]byte(`logging:
+  stats:
+    handler:
+      - typ: memory
+        name: recent
+        level: info
+      - typ: memory
+        level: error`))
+	l := L("stats")
+	l.Debug("skip")
+	l.Info("hello")
+	l.Error("failed")
+	observeRotate("/tmp/stats.log", time.Now().Add(-time.Millisecond), nil)
+	observeRotate("/tmp/stats.log", time.Now(), errors.New("rename"))
+	observeEmail("stats", errors.New("smtp"))
+
+	var recent, errs *HandlerStats
+	s := Stats()
+	for i, h := range s.Handlers {
+		if h.Module == "stats" && h.Handler == "recent" {
+			recent = &s.Handlers[i]
+		}
+		if h.Module == "stats" && h.Handler == "memory.1" {
+			errs = &s.Handlers[i]
+		}
+	}
+	if recent == nil || recent.Entries != 2 || recent.Bytes == 0 || recent.Latency.Count != 2 {
+		t.Errorf("recent=%+v", recent)
+	}
+	if errs == nil || errs.Entries != 1 {
+		t.Errorf("memory.1=%+v", errs)
+	}
+
+	rec := httptest.NewRecorder()
+	PrometheusHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE logger_handler_entries_total counter",
+		`logger_handler_entries_total{module="stats",handler="recent"} 2`,
+		`logger_handler_write_seconds_bucket{module="stats",handler="recent",le="+Inf"} 2`,
+		`logger_handler_write_seconds_count{module="stats",handler="recent"} 2`,
+		`logger_rotate_total{file="/tmp/stats.log"} 2`,
+		`logger_rotate_errors_total{file="/tmp/stats.log"} 1`,
+		`logger_email_failed_total{module="stats"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("%s not found:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package logger
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ==========================
+// @Author : zero-miao
+// @Date   : 2026-10-20 01:00
+// @File   : validate.go
+// @Project: utils/logger
+// ==========================
+
+// 问题等级
+const (
+	ProblemError   = "error"   // YamlInit 会 panic 或配置不会生效
+	ProblemWarning = "warning" // 可以运行, 但可能不是预期的行为
+)
+
+// Problem 配置中的一个问题.
+type Problem struct {
+	Path     string // yaml 路径, 如 logging.app.handler[0].level
+	Severity string
+	Message  string
+}
+
+func (p Problem) String() string {
+	if p.Path == "" {
+		return p.Severity + ": " + p.Message
+	}
+	return p.Path + ": " + p.Severity + ": " + p.Message
+}
+
+// builtinHandlerTypes 内置的 handler 类型, 按 HandlerConfig 检查. 其他注册的类型只检查通用配置, 并调用其 factory.
+var builtinHandlerTypes = map[string]bool{
+	"file": true, "rotate_file": true, "email": true, "syslog": true,
+	"tcp": true, "udp": true, "http": true, "memory": true,
+}
+
+// Validate 检查 yaml 配置, 返回所有问题, 没有问题时返回 nil.
+// 配置先经过 ResolveConfig(环境变量替换和覆盖), 与 YamlInit 看到的内容一致. 不会创建 writer(打开文件, 连接等).
+func Validate(data []byte) []Problem {
+	v := &validator{}
+	resolved, err := ResolveConfig(data)
+	if err != nil {
+		v.errorf("", "%v", err)
+		return v.problems
+	}
+	var root yaml.MapSlice
+	if err := yaml.Unmarshal(resolved, &root); err != nil {
+		v.errorf("", "%v", err)
+		return v.problems
+	}
+	raw, ok := mapGet(root, "logging")
+	if !ok {
+		v.errorf("logging", "missing")
+		return v.problems
+	}
+	modules, _ := raw.(yaml.MapSlice)
+	if len(modules) == 0 {
+		v.warnf("logging", "no module")
+	}
+	for _, item := range modules {
+		name := fmt.Sprint(item.Key)
+		node, _ := item.Value.(yaml.MapSlice)
+		v.module(name, node)
+	}
+	return v.problems
+}
+
+type validator struct {
+	problems []Problem
+}
+
+func (v *validator) errorf(path, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{Path: path, Severity: ProblemError, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(path, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{Path: path, Severity: ProblemWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) check(path string, err error) {
+	if err != nil {
+		v.errorf(path, "%v", err)
+	}
+}
+
+// yamlKeys 返回 struct 中各字段 yaml tag 的名称.
+func yamlKeys(typ reflect.Type) map[string]bool {
+	known := map[string]bool{}
+	for i := 0; i < typ.NumField(); i++ {
+		if tag := strings.Split(typ.Field(i).Tag.Get("yaml"), ",")[0]; tag != "" && tag != "-" {
+			known[tag] = true
+		}
+	}
+	return known
+}
+
+// unknownKeys 报告 struct 中没有对应 yaml tag 的 key.
+func (v *validator) unknownKeys(path string, node yaml.MapSlice, typ reflect.Type) {
+	known := yamlKeys(typ)
+	for _, item := range node {
+		key := fmt.Sprint(item.Key)
+		if !known[key] {
+			v.errorf(path+"."+key, "unknown key")
+		}
+	}
+}
+
+func (v *validator) module(name string, node yaml.MapSlice) {
+	path := "logging." + name
+	// 逐个 module 解析, 类型错误时仍然检查其他配置.
+	var cfg ConfigHandler
+	data, err := yaml.Marshal(node)
+	if err == nil {
+		err = yaml.Unmarshal(data, &cfg)
+	}
+	v.check(path, err)
+	v.unknownKeys(path, node, reflect.TypeOf(cfg))
+	if cfg.StacktraceLevel != "" {
+		if _, ok := LogLevelMap[cfg.StacktraceLevel]; !ok {
+			v.errorf(path+".stacktrace_level", "invalid level: %s", cfg.StacktraceLevel)
+		}
+	}
+	if cfg.CallerSkip < 0 {
+		v.errorf(path+".caller_skip", "must not be negative")
+	}
+	if len(cfg.Redact) > 0 {
+		_, err := NewRedactor(cfg.Redact)
+		v.check(path+".redact", err)
+	}
+	if len(cfg.Handler) == 0 {
+		v.warnf(path+".handler", "no handler, logs of this module are discarded")
+	}
+	names := map[string]int{}
+	for i := range cfg.Handler {
+		h := &cfg.Handler[i]
+		hpath := fmt.Sprintf("%s.handler[%d]", path, i)
+		if h.Name != "" {
+			if j, ok := names[h.Name]; ok {
+				v.errorf(hpath+".name", "duplicate name %q (handler[%d])", h.Name, j)
+			}
+			names[h.Name] = i
+		}
+		v.handler(name, i, hpath, h)
+	}
+}
+
+func (v *validator) handler(module string, index int, path string, h *HandlerConfig) {
+	node, err := h.node()
+	if err != nil {
+		v.errorf(path, "%v", err)
+		return
+	}
+	raw := &RawNode{Module: module, Index: index, Typ: h.Typ, node: node}
+	v.common(path, raw)
+	if h.Typ == "" {
+		v.errorf(path+".typ", "missing, support: %s", strings.Join(HandlerTypes(), ", "))
+		return
+	}
+	if !builtinHandlerTypes[h.Typ] {
+		handlerTypesMu.RLock()
+		factory, ok := handlerTypes[h.Typ]
+		handlerTypesMu.RUnlock()
+		if !ok {
+			v.errorf(path+".typ", "invalid typ %q, support: %s", h.Typ, strings.Join(HandlerTypes(), ", "))
+			return
+		}
+		handler, err := factory(raw)
+		v.check(path, err)
+		if err == nil && handler == nil {
+			v.errorf(path, "factory returned nil handler")
+		}
+		return
+	}
+
+	// HandlerConfig.UnmarshalYAML 忽略字段的类型错误, 这里按 plain 类型解析以便报告.
+	type plain HandlerConfig
+	var p plain
+	v.check(path, raw.Decode(&p))
+	cfg := HandlerConfig(p)
+	v.unknownKeys(path, node, reflect.TypeOf(cfg))
+	if cfg.Level == "" {
+		v.errorf(path+".level", "missing")
+	} else if _, err := ParseLevel(cfg.Level); err != nil {
+		v.errorf(path+".level", "invalid level: %s", cfg.Level)
+	}
+	switch cfg.Format {
+	case "", "console", "json", "logfmt":
+	default:
+		v.errorf(path+".format", "invalid format %q, support: console, json, logfmt", cfg.Format)
+	}
+	if cfg.Encoder != nil {
+		_, err := cfg.Encoder.ZapConfig()
+		v.check(path+".encoder", err)
+	}
+	duration := func(key, value string) {
+		if du, err := cfg.ParseDuration(key, value); err != nil || du < 0 {
+			v.errorf(path+"."+key, "invalid duration: %s", value)
+		}
+	}
+	required := func(key, value string) {
+		if value == "" {
+			v.errorf(path+"."+key, "missing")
+		}
+	}
+	buffer := func() {
+		if cfg.Buffer != nil {
+			_, err := cfg.Buffer.Option()
+			v.check(path+".buffer", err)
+		}
+	}
+	reopen := func() {
+		if cfg.Reopen {
+			duration("reopen_check", cfg.ReopenCheck)
+		}
+	}
+
+	switch cfg.Typ {
+	case "file":
+		required("filename", cfg.Filename)
+		buffer()
+		reopen()
+	case "rotate_file":
+		required("filename", cfg.Filename)
+		buffer()
+		reopen()
+		if cfg.Duration == "" {
+			v.errorf(path+".duration", "missing")
+		} else if du, err := time.ParseDuration(cfg.Duration); err != nil || du <= 0 {
+			v.errorf(path+".duration", "invalid duration: %s", cfg.Duration)
+		}
+		if cfg.Replica < 0 {
+			v.errorf(path+".replica", "must not be negative")
+		}
+		_, err := cfg.Retention.Policy(cfg.Replica)
+		v.check(path+".retention", err)
+		for j, hook := range cfg.Hooks {
+			v.hook(module, fmt.Sprintf("%s.hooks[%d]", path, j), j, hook)
+		}
+		if cfg.HookRetry < 0 {
+			v.errorf(path+".hook_retry", "must not be negative")
+		}
+		duration("hook_backoff", cfg.HookBackoff)
+		if len(cfg.Hooks) == 0 && (cfg.HookRetry != 0 || cfg.HookBackoff != "") {
+			v.warnf(path+".hooks", "hook_retry/hook_backoff set without hooks")
+		}
+	case "email":
+		duration("min_interval", cfg.MinInterval)
+	case "syslog":
+		if cfg.Facility != "" {
+			if _, ok := SyslogFacilityMap[cfg.Facility]; !ok {
+				v.errorf(path+".facility", "invalid facility: %s", cfg.Facility)
+			}
+		}
+		switch cfg.Network {
+		case "", "unix", "unixgram", "udp", "tcp":
+		default:
+			v.errorf(path+".network", "invalid network %q, support: unix, unixgram, udp, tcp", cfg.Network)
+		}
+		if cfg.Network != "" && cfg.Network != "unix" && cfg.Network != "unixgram" {
+			required("address", cfg.Address)
+		}
+		duration("timeout", cfg.Timeout)
+	case "tcp", "udp":
+		required("address", cfg.Address)
+		buffer()
+		duration("timeout", cfg.Timeout)
+	case "http":
+		required("url", cfg.URL)
+		switch cfg.Style {
+		case "", HTTPStyleNDJSON, HTTPStyleESBulk:
+		default:
+			v.errorf(path+".style", "invalid style %q, support: %s, %s", cfg.Style, HTTPStyleNDJSON, HTTPStyleESBulk)
+		}
+		if cfg.BatchSize < 0 {
+			v.errorf(path+".batch_size", "must not be negative")
+		}
+		duration("flush_interval", cfg.FlushInterval)
+		duration("timeout", cfg.Timeout)
+	case "memory":
+		if cfg.Size < 0 {
+			v.errorf(path+".size", "must not be negative")
+		}
+		if cfg.Format != "" {
+			v.warnf(path+".format", "ignored, memory handler always uses json")
+		}
+	}
+}
+
+// common 检查所有类型通用的配置.
+func (v *validator) common(path string, node *RawNode) {
+	var common struct {
+		Sampling *SamplingConfig `yaml:"sampling"`
+		Filter   *FilterConfig   `yaml:"filter"`
+	}
+	if err := node.Decode(&common); err != nil {
+		v.errorf(path, "%v", err)
+		return
+	}
+	if common.Sampling != nil {
+		_, err := common.Sampling.samplerWrap(node.Module)
+		v.check(path+".sampling", err)
+	}
+	if common.Filter != nil {
+		_, err := common.Filter.compile()
+		v.check(path+".filter", err)
+	}
+}
+
+func (v *validator) hook(module, path string, index int, node yaml.MapSlice) {
+	raw, _ := mapGet(node, "typ")
+	typ, _ := raw.(string)
+	rotateHookTypesMu.RLock()
+	factory, ok := rotateHookTypes[typ]
+	rotateHookTypesMu.RUnlock()
+	if !ok {
+		v.errorf(path+".typ", "invalid hook typ %q, support: %s", typ, strings.Join(RotateHookTypes(), ", "))
+		return
+	}
+	_, err := factory(&RawNode{Module: module, Index: index, Typ: typ, node: node})
+	v.check(path, err)
+}
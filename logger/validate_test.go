@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestValidateSample(t *testing.T) {
+	data, err := os.ReadFile("sample.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range Validate(data) {
+		if p.Severity == ProblemError {
+			t.Errorf("sample.yaml: %s", p)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	problems := Validate([]byte(`logging:
+  app:
+    caller: true
+    stacktrace_level: fatel
+    redact:
+      - mode: full
+    handler:
+      - typ: rotate_file
+        filename: /tmp/app.log
+        level: info
+        format: jsno
+        duration: 1x
+        levle: debug
+        hooks:
+          - typ: upload
+          - typ: archive
+        retention:
+          max_size: 10XB
+      - typ: file
+        level: [info]
+        name: out
+      - typ: stdout
+        level: info
+        name: out
+      - typ: tcp
+        level: warn
+        filter:
+          min_level: error
+          max_level: warn
+        sampling:
+          tick: -1s
+  empty:
+    handler: []`))
+	got := make([]string, 0, len(problems))
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	want := []string{
+		"logging.app.stacktrace_level: error: invalid level: fatel",
+		"logging.app.redact: error: redact[0]: field or value required",
+		"logging.app.handler[0].levle: error: unknown key",
+		"logging.app.handler[0].format: error: invalid format \"jsno\", support: console, json, logfmt",
+		"logging.app.handler[0].duration: error: invalid duration: 1x",
+		"logging.app.handler[0].retention: error: invalid retention max_size: 10XB",
+		"logging.app.handler[0].hooks[0].typ: error: invalid hook typ \"upload\"",
+		"logging.app.handler[0].hooks[1]: error: archive dir required",
+		"logging.app.handler[1]: error: yaml: unmarshal errors",
+		"logging.app.handler[1].level: error: missing",
+		"logging.app.handler[1].filename: error: missing",
+		"logging.app.handler[2].name: error: duplicate name \"out\" (handler[1])",
+		"logging.app.handler[2].typ: error: invalid typ \"stdout\"",
+		"logging.app.handler[3].sampling: error: invalid sampling tick: -1s",
+		"logging.app.handler[3].filter: error: invalid filter: min_level > max_level",
+		"logging.app.handler[3].address: error: missing",
+		"logging.empty.handler: warning: no handler",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d problems, want %d:\n%s", len(got), len(want), strings.Join(got, "\n"))
+	}
+	for _, w := range want {
+		found := false
+		for _, g := range got {
+			found = found || strings.HasPrefix(g, w)
+		}
+		if !found {
+			t.Errorf("missing problem %q in:\n%s", w, strings.Join(got, "\n"))
+		}
+	}
+
+	if p := Validate([]byte("logging: [")); len(p) != 1 || p[0].Severity != ProblemError {
+		t.Errorf("syntax error: %v", p)
+	}
+	if p := Validate(nil); len(p) != 0 {
+		t.Errorf("default config: %v", p)
+	}
+}